@@ -30,11 +30,13 @@ func main() {
 	}
 
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	digest := tdigest.New(500)
+	digest := tdigest.New(tdigest.WithCompression(500))
 
 	for i := 0; i < 1e8; i++ {
 		val := r.Float64()
-		digest.Add(val)
+		if err := digest.Add(val); err != nil {
+			log.Fatal(err)
+		}
 	}
 
 	fmt.Println(digest.String())
@@ -42,7 +44,7 @@ func main() {
 	err := 0.0
 	for i := 0; i <= 10; i++ {
 		p := float64(i) / 10
-		q := digest.Quantile(p)
+		q := digest.MustQuantile(p)
 		//fmt.Println(i, q)
 		err += (p - q) * (p - q)
 	}
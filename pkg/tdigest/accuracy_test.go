@@ -0,0 +1,230 @@
+package tdigest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestQuantileAccuracy(t *testing.T) {
+	// Smaller compressions are more accurate since centroids hold at most
+	// about compression observations. At 20 every case below is within 1% of
+	// the sample range; at 50 uniform is not.
+	const compression = 20
+
+	tcs := []struct {
+		name   string
+		sample func(r *rand.Rand) float64
+	}{{
+		name:   "uniform",
+		sample: func(r *rand.Rand) float64 { return r.Float64() },
+	}, {
+		name:   "normal",
+		sample: func(r *rand.Rand) float64 { return r.NormFloat64() },
+	}, {
+		name:   "exponential",
+		sample: func(r *rand.Rand) float64 { return r.ExpFloat64() },
+	}, {
+		name:   "log-normal",
+		sample: func(r *rand.Rand) float64 { return math.Exp(r.NormFloat64()) },
+	}, {
+		name: "pareto",
+		sample: func(r *rand.Rand) float64 {
+			// Inverse transform sampling with xm = 1 and alpha = 2.
+			return 1 / math.Sqrt(1-r.Float64())
+		},
+	}, {
+		name:   "degenerate",
+		sample: func(r *rand.Rand) float64 { return 3 },
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			vals := make([]float64, 10000)
+			digest := New(WithCompression(compression))
+			for i := range vals {
+				vals[i] = tc.sample(r)
+				digest.Add(vals[i])
+			}
+			sort.Float64s(vals)
+
+			tolerance := 0.01 * (vals[len(vals)-1] - vals[0])
+			for _, q := range []float64{0.01, 0.1, 0.5, 0.9, 0.99} {
+				got := digest.quantile(q)
+				want := sampleQuantile(vals, q)
+				if math.Abs(got-want) > tolerance {
+					t.Errorf("got Quantile(%v) = %v, want %v +/- %v", q, got, want, tolerance)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkScaleFunctions reports, for each scale function and distribution,
+// the number of centroids after adding 100000 values, the error at p99 and
+// averaged over 1000 random quantiles, and the rate of adds. Errors are
+// relative to the exact p99.
+//
+// Run with -benchtime=1x to print a table for the README.
+func BenchmarkScaleFunctions(b *testing.B) {
+	const n = 100000
+
+	scaleFuncs := []struct {
+		name string
+		sf   ScaleFunc
+	}{{
+		name: "k1",
+		sf:   ScaleFuncK1,
+	}, {
+		name: "k2",
+		sf:   ScaleFuncK2,
+	}, {
+		name: "k3",
+		sf:   ScaleFuncK3,
+	}}
+
+	r := rand.New(rand.NewSource(1))
+	distributions := []struct {
+		name string
+		next func() float64
+		// maxP99Error is the largest acceptable error at p99, relative to the
+		// exact p99.
+		maxP99Error float64
+	}{{
+		name:        "uniform",
+		next:        r.Float64,
+		maxP99Error: 0.01,
+	}, {
+		name:        "normal",
+		next:        r.NormFloat64,
+		maxP99Error: 0.05,
+	}, {
+		// Pareto with a minimum of 1 and shape 2, for a heavy right tail.
+		name:        "pareto",
+		next:        func() float64 { return 1 / math.Sqrt(1-r.Float64()) },
+		maxP99Error: 0.1,
+	}}
+
+	queries := make([]float64, 1000)
+	for i := range queries {
+		queries[i] = r.Float64()
+	}
+
+	for _, dist := range distributions {
+		vals := make([]float64, n)
+		for i := range vals {
+			vals[i] = dist.next()
+		}
+		sorted := make([]float64, n)
+		copy(sorted, vals)
+		sort.Float64s(sorted)
+		p99 := sampleQuantile(sorted, 0.99)
+
+		for _, tc := range scaleFuncs {
+			b.Run(dist.name+"/"+tc.name, func(b *testing.B) {
+				var digest *TDigest
+				for i := 0; i < b.N; i++ {
+					digest = New(WithScaleFunc(tc.sf))
+					for _, v := range vals {
+						digest.Add(v)
+					}
+				}
+				b.ReportMetric(float64(n)*float64(b.N)/b.Elapsed().Seconds(), "adds/s")
+				b.ReportMetric(float64(digest.nCentroids), "centroids")
+
+				p99Error := math.Abs(digest.quantile(0.99)-p99) / math.Abs(p99)
+				b.ReportMetric(p99Error, "p99-err")
+
+				var sumError float64
+				for _, q := range queries {
+					sumError += math.Abs(digest.quantile(q) - sampleQuantile(sorted, q))
+				}
+				b.ReportMetric(sumError/float64(len(queries)), "mean-err")
+
+				if p99Error > dist.maxP99Error {
+					b.Errorf("got p99 error %v, want at most %v", p99Error, dist.maxP99Error)
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkAccuracy reports, for each distribution, number of values and
+// compression, the largest absolute and relative errors of Quantile at p50,
+// p90, p95, p99 and p99.9 against the exact quantiles of the values. Relative
+// errors are relative to the exact quantile, so are large where it is near
+// zero, as for the normal median.
+//
+// Remember that a smaller compression keeps more centroids, so is more
+// accurate. Run with -benchtime=1x to print a calibration table.
+func BenchmarkAccuracy(b *testing.B) {
+	qs := []float64{0.5, 0.9, 0.95, 0.99, 0.999}
+
+	distributions := []struct {
+		name string
+		next func(r *rand.Rand) float64
+	}{{
+		name: "uniform",
+		next: func(r *rand.Rand) float64 { return r.Float64() },
+	}, {
+		name: "normal",
+		next: func(r *rand.Rand) float64 { return r.NormFloat64() },
+	}, {
+		name: "exponential",
+		next: func(r *rand.Rand) float64 { return r.ExpFloat64() },
+	}, {
+		// Pareto with a minimum of 1 and shape 2, for a heavy right tail.
+		name: "pareto",
+		next: func(r *rand.Rand) float64 { return 1 / math.Sqrt(1-r.Float64()) },
+	}, {
+		// Two normals far enough apart to leave a gap between them, weighted
+		// so the median isn't in the gap.
+		name: "bimodal",
+		next: func(r *rand.Rand) float64 {
+			if r.Float64() < 0.7 {
+				return r.NormFloat64() - 5
+			}
+			return r.NormFloat64() + 5
+		},
+	}}
+
+	for _, dist := range distributions {
+		for _, n := range []int{1000, 10000, 100000, 1000000} {
+			r := rand.New(rand.NewSource(1))
+			vals := make([]float64, n)
+			for i := range vals {
+				vals[i] = dist.next(r)
+			}
+			sorted := make([]float64, n)
+			copy(sorted, vals)
+			sort.Float64s(sorted)
+
+			for _, compression := range []float64{100, 200, 500, 1000} {
+				name := fmt.Sprintf("%s/n=%d/c=%v", dist.name, n, compression)
+				b.Run(name, func(b *testing.B) {
+					var digest *TDigest
+					for i := 0; i < b.N; i++ {
+						digest = New(WithCompression(compression))
+						for _, v := range vals {
+							digest.Add(v)
+						}
+					}
+
+					var maxAbs, maxRel float64
+					for _, q := range qs {
+						want := sampleQuantile(sorted, q)
+						absErr := math.Abs(digest.quantile(q) - want)
+						maxAbs = math.Max(maxAbs, absErr)
+						maxRel = math.Max(maxRel, absErr/math.Abs(want))
+					}
+					b.ReportMetric(float64(digest.nCentroids), "centroids")
+					b.ReportMetric(maxAbs, "max-abs-err")
+					b.ReportMetric(maxRel, "max-rel-err")
+				})
+			}
+		}
+	}
+}
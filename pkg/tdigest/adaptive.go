@@ -0,0 +1,135 @@
+package tdigest
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// adaptiveCheckInterval is the number of values added to an AdaptiveDigest
+	// between checks of its tail error.
+	adaptiveCheckInterval = 10000
+
+	// adaptiveReservoirSize is the number of values an AdaptiveDigest samples
+	// to estimate the exact 99th percentile.
+	adaptiveReservoirSize = 10000
+
+	// minAdaptiveCompression and maxAdaptiveCompression bound the compressions
+	// an AdaptiveDigest adjusts between.
+	minAdaptiveCompression = 1
+	maxAdaptiveCompression = 1 << 16
+)
+
+// AdaptiveDigest adjusts its compression to keep the error of its 99th
+// percentile estimate below a target fraction of the range of observed values.
+//
+// Every adaptiveCheckInterval values, the estimate of the 99th percentile is
+// compared with the exact 99th percentile of a uniform random sample of all
+// values added so far. If the error exceeds the target, the compression is
+// halved; if it is under half the target, the compression is doubled. In this
+// package a centroid absorbs more observations the higher the compression, so
+// lowering the compression increases accuracy.
+//
+// Compression changes one step per check, so reaching a compression k steps
+// away from DefaultCompression takes k*adaptiveCheckInterval values. Changing
+// compression rebuilds the TDigest from its centroids, so accuracy already lost
+// to merged centroids isn't recovered; only later values benefit. The sample
+// always uses memory for adaptiveReservoirSize values, and each halving of the
+// compression roughly doubles the number of centroids.
+type AdaptiveDigest struct {
+	digest *TDigest
+
+	targetTailError float64
+
+	min, max float64
+
+	// reservoir is a uniform random sample of all added values.
+	reservoir []float64
+	seen      int
+	rng       *rand.Rand
+}
+
+// NewAdaptive returns an empty AdaptiveDigest which starts at
+// DefaultCompression and keeps the error of its 99th percentile below
+// targetTailError times the range of observed values.
+func NewAdaptive(targetTailError float64) *AdaptiveDigest {
+	return &AdaptiveDigest{
+		digest:          New(),
+		targetTailError: targetTailError,
+		min:             math.Inf(1),
+		max:             math.Inf(-1),
+		reservoir:       make([]float64, 0, adaptiveReservoirSize),
+		rng:             rand.New(rand.NewSource(1)),
+	}
+}
+
+// Add adds val to the AdaptiveDigest, adjusting the compression if a check is
+// due. It returns ErrInvalidValue if val is NaN or infinite.
+func (d *AdaptiveDigest) Add(val float64) error {
+	if err := d.digest.Add(val); err != nil {
+		return err
+	}
+	d.min = math.Min(d.min, val)
+	d.max = math.Max(d.max, val)
+
+	// Algorithm R reservoir sampling.
+	d.seen++
+	if len(d.reservoir) < adaptiveReservoirSize {
+		d.reservoir = append(d.reservoir, val)
+	} else if i := d.rng.Intn(d.seen); i < adaptiveReservoirSize {
+		d.reservoir[i] = val
+	}
+
+	if d.seen%adaptiveCheckInterval == 0 {
+		d.adapt()
+	}
+	return nil
+}
+
+// TailError returns the absolute error of the 99th percentile estimate,
+// relative to the sampled exact 99th percentile. It is 0 if no values have been
+// added.
+func (d *AdaptiveDigest) TailError() float64 {
+	if len(d.reservoir) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(d.reservoir))
+	copy(sorted, d.reservoir)
+	sort.Float64s(sorted)
+
+	exact := sorted[int(0.99*float64(len(sorted)-1))]
+	return math.Abs(d.digest.quantile(0.99) - exact)
+}
+
+// adapt halves or doubles the compression if the tail error is outside the
+// target.
+func (d *AdaptiveDigest) adapt() {
+	bound := d.targetTailError * (d.max - d.min)
+	tailError := d.TailError()
+
+	compression := d.digest.compression
+	switch {
+	case tailError > bound && compression/2 >= minAdaptiveCompression:
+		compression /= 2
+	case tailError < bound/2 && compression*2 <= maxAdaptiveCompression:
+		compression *= 2
+	default:
+		return
+	}
+
+	rebuilt := New(WithCompression(compression))
+	rebuilt.Accumulate(d.digest)
+	d.digest = rebuilt
+}
+
+// CurrentCompression returns the compression currently in use.
+func (d *AdaptiveDigest) CurrentCompression() float64 {
+	return d.digest.compression
+}
+
+// Quantile returns the quantile q of all added values, or ErrEmptyDigest if
+// none have been added.
+func (d *AdaptiveDigest) Quantile(q float64) (float64, error) {
+	return d.digest.Quantile(q)
+}
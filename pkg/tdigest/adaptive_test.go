@@ -0,0 +1,68 @@
+package tdigest_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+// addShifting adds n normally-distributed values whose mean slowly increases.
+func addShifting(d *tdigest.AdaptiveDigest, n int) (min, max float64) {
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < n; i++ {
+		val := r.NormFloat64() + float64(i)/100000
+		if i == 0 || val < min {
+			min = val
+		}
+		if i == 0 || val > max {
+			max = val
+		}
+		d.Add(val)
+	}
+	return min, max
+}
+
+func TestAdaptiveDigest(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		targetTailError float64
+	}{
+		{"tight", 0.01},
+		{"loose", 0.03},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := tdigest.NewAdaptive(tc.targetTailError)
+			if got := d.CurrentCompression(); got != tdigest.DefaultCompression {
+				t.Errorf("got initial CurrentCompression() = %v, want %v", got, tdigest.DefaultCompression)
+			}
+			if got := d.TailError(); got != 0 {
+				t.Errorf("got TailError() = %v with no values, want 0", got)
+			}
+
+			min, max := addShifting(d, 500000)
+
+			if got := d.CurrentCompression(); got == tdigest.DefaultCompression {
+				t.Errorf("got CurrentCompression() = %v, want compression to have adapted", got)
+			}
+			if got, bound := d.TailError(), tc.targetTailError*(max-min); got > bound {
+				t.Errorf("got TailError() = %v, want at most %v", got, bound)
+			}
+		})
+	}
+}
+
+func TestAdaptiveDigest_Direction(t *testing.T) {
+	// Tighter targets need more centroids, so lower compressions.
+	tight := tdigest.NewAdaptive(0.002)
+	addShifting(tight, 200000)
+	if got := tight.CurrentCompression(); got >= tdigest.DefaultCompression {
+		t.Errorf("got CurrentCompression() = %v for tight target, want less than %v", got, tdigest.DefaultCompression)
+	}
+
+	loose := tdigest.NewAdaptive(0.1)
+	addShifting(loose, 200000)
+	if got := loose.CurrentCompression(); got <= tdigest.DefaultCompression {
+		t.Errorf("got CurrentCompression() = %v for loose target, want more than %v", got, tdigest.DefaultCompression)
+	}
+}
@@ -0,0 +1,48 @@
+package tdigest
+
+import "fmt"
+
+// AggregateMultiple partitions vals by key and returns a TDigest of the values
+// for each unique key.
+func AggregateMultiple(key func(float64) string, vals []float64, compression float64) map[string]*TDigest {
+	return aggregate(vals, compression, func(i int) string {
+		return key(vals[i])
+	})
+}
+
+// AggregateMultipleKeyed returns a TDigest of the values for each unique key,
+// where keys[i] is the key of vals[i].
+//
+// AggregateMultipleKeyed panics if keys and vals have different lengths.
+func AggregateMultipleKeyed(keys []string, vals []float64, compression float64) map[string]*TDigest {
+	if len(keys) != len(vals) {
+		panic(fmt.Sprintf("tdigest: got %d keys for %d values", len(keys), len(vals)))
+	}
+	return aggregate(vals, compression, func(i int) string {
+		return keys[i]
+	})
+}
+
+// aggregate returns a TDigest of the values for each unique key, where
+// keyOf(i) is the key of vals[i].
+func aggregate(vals []float64, compression float64, keyOf func(i int) string) map[string]*TDigest {
+	result := make(map[string]*TDigest)
+	var lastKey string
+	var last *TDigest
+	for i, val := range vals {
+		// Keys commonly repeat, so skip the map lookup if the key is the same as
+		// the previous one.
+		key := keyOf(i)
+		if last == nil || key != lastKey {
+			last = result[key]
+			if last == nil {
+				last = New(WithCompression(compression))
+				result[key] = last
+			}
+			lastKey = key
+		}
+		// Invalid values are skipped.
+		_ = last.Add(val)
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+package tdigest
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func decile(val float64) string {
+	return string(rune('0' + int(val*10)))
+}
+
+func randomVals(n int, seed int64) []float64 {
+	r := rand.New(rand.NewSource(seed))
+	vals := make([]float64, n)
+	for i := range vals {
+		vals[i] = r.Float64()
+	}
+	return vals
+}
+
+func TestAggregateMultiple(t *testing.T) {
+	vals := randomVals(10000, 1)
+	digests := AggregateMultiple(decile, vals, 100)
+
+	if len(digests) != 10 {
+		t.Errorf("got %d digests, want %d", len(digests), 10)
+	}
+
+	var total float64
+	for key, d := range digests {
+		total += d.count
+		for _, c := range d.centroids {
+			if got := decile(c.mean); got != key {
+				t.Errorf("got centroid with mean %v in digest %q", c.mean, key)
+			}
+		}
+	}
+	if total != float64(len(vals)) {
+		t.Errorf("got total count %v, want %v", total, len(vals))
+	}
+}
+
+func TestAggregateMultipleKeyed(t *testing.T) {
+	keys := []string{"a", "a", "b", "a", "b"}
+	vals := []float64{1, 2, 10, 3, 20}
+	digests := AggregateMultipleKeyed(keys, vals, 100)
+
+	if got := digests["a"].count; got != 3 {
+		t.Errorf("got count %v for a, want %v", got, 3)
+	}
+	if got := digests["a"].mean(); got != 2 {
+		t.Errorf("got mean %v for a, want %v", got, 2)
+	}
+	if got := digests["b"].count; got != 2 {
+		t.Errorf("got count %v for b, want %v", got, 2)
+	}
+	if got := digests["b"].mean(); got != 15 {
+		t.Errorf("got mean %v for b, want %v", got, 15)
+	}
+}
+
+func BenchmarkAggregateMultiple(b *testing.B) {
+	vals := randomVals(100000, 1)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = AggregateMultiple(decile, vals, 100)
+	}
+}
+
+func BenchmarkAggregateMultiple_Loop(b *testing.B) {
+	vals := randomVals(100000, 1)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		digests := make(map[string]*TDigest)
+		for _, val := range vals {
+			key := decile(val)
+			d, ok := digests[key]
+			if !ok {
+				d = New(WithCompression(100))
+				digests[key] = d
+			}
+			d.Add(val)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package tdigest
+
+import "sync/atomic"
+
+// AtomicTDigest is a TDigest which may be merged into from many goroutines
+// without locking.
+//
+// Merges never modify a TDigest once it has been published. Instead, each
+// merge creates a merged copy and atomically swaps it in, so readers of Load
+// always see a consistent TDigest.
+type AtomicTDigest struct {
+	digest atomic.Pointer[TDigest]
+}
+
+// NewAtomic returns an empty AtomicTDigest with the given compression.
+func NewAtomic(compression float64) *AtomicTDigest {
+	a := &AtomicTDigest{}
+	a.digest.Store(New(WithCompression(compression)))
+	return a
+}
+
+// Load returns the current TDigest. The returned TDigest is shared and must not
+// be modified.
+func (a *AtomicTDigest) Load() *TDigest {
+	return a.digest.Load()
+}
+
+// CASMerge attempts to merge other into the AtomicTDigest. It returns false if
+// another goroutine modified the AtomicTDigest first, in which case the caller
+// should retry.
+func (a *AtomicTDigest) CASMerge(other *TDigest) bool {
+	current := a.digest.Load()
+	merged := current.Clone()
+	merged.Accumulate(other)
+	return a.digest.CompareAndSwap(current, merged)
+}
+
+// AtomicMerge merges other into the AtomicTDigest, retrying until it succeeds.
+func (a *AtomicTDigest) AtomicMerge(other *TDigest) {
+	for !a.CASMerge(other) {
+	}
+}
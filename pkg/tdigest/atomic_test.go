@@ -0,0 +1,43 @@
+package tdigest
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestAtomicTDigest_AtomicMerge(t *testing.T) {
+	a := NewAtomic(100)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		partial := newUniform(100, 1000, int64(i))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.AtomicMerge(partial)
+		}()
+	}
+	wg.Wait()
+
+	if got := a.Load().count; got != 100000 {
+		t.Errorf("got count %v, want %v", got, 100000)
+	}
+	if got := a.Load().quantile(0.5); got < 0.45 || got > 0.55 {
+		t.Errorf("got Quantile(0.5) = %v, want approximately 0.5", got)
+	}
+}
+
+func TestAtomicTDigest_CASMerge(t *testing.T) {
+	a := NewAtomic(100)
+	before := a.Load()
+
+	if !a.CASMerge(newUniform(100, 1000, 1)) {
+		t.Fatal("got CASMerge() = false without contention")
+	}
+	if before.count != 0 {
+		t.Errorf("merge modified published digest: got count %v, want 0", before.count)
+	}
+	if got := a.Load().count; got != 1000 {
+		t.Errorf("got count %v, want %v", got, 1000)
+	}
+}
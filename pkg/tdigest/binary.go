@@ -0,0 +1,184 @@
+package tdigest
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// binaryVersion is the version byte of the TDigest binary format. Version 1
+// had no sum, and is still decoded.
+const binaryVersion = 2
+
+// binaryHeaderSize is the size of the version byte, compression, count, sum,
+// and number of centroids.
+const binaryHeaderSize = 1 + 8 + 8 + 8 + 8
+
+// binaryHeaderSizeV1 is the size of the header of version 1, which has no sum.
+const binaryHeaderSizeV1 = 1 + 8 + 8 + 8
+
+var (
+	_ encoding.BinaryMarshaler   = (*TDigest)(nil)
+	_ encoding.BinaryUnmarshaler = (*TDigest)(nil)
+)
+
+var errTruncatedBinary = errors.New("tdigest: truncated TDigest")
+
+// MarshalBinary encodes the TDigest as a version byte, the compression, the
+// total count, the sum, the number of centroids, and then each centroid's mean
+// and count. All numbers are little-endian. The compression of a zero TDigest
+// is encoded as DefaultCompression.
+func (d *TDigest) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, binaryHeaderSize+16*d.nCentroids)
+	b = append(b, binaryVersion)
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(d.Compression()))
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(d.count))
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(d.sum))
+	b = binary.LittleEndian.AppendUint64(b, uint64(d.nCentroids))
+	for _, c := range d.centroids {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(c.mean))
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(c.count))
+	}
+	return b, nil
+}
+
+// UnmarshalBinary replaces the TDigest with one encoded by MarshalBinary. The
+// sum of a TDigest encoded by version 1 is computed from its centroids. It
+// returns an error if the compression is not positive and finite, as do the
+// other decoders.
+func (d *TDigest) UnmarshalBinary(b []byte) error {
+	if len(b) < 1 {
+		return errTruncatedBinary
+	}
+	version := b[0]
+	headerSize := binaryHeaderSize
+	switch version {
+	case binaryVersion:
+	case 1:
+		headerSize = binaryHeaderSizeV1
+	default:
+		return fmt.Errorf("tdigest: unknown TDigest version %d", version)
+	}
+	if len(b) < headerSize {
+		return errTruncatedBinary
+	}
+
+	compression := math.Float64frombits(binary.LittleEndian.Uint64(b[1:]))
+	count := math.Float64frombits(binary.LittleEndian.Uint64(b[9:]))
+	var sum float64
+	if version != 1 {
+		sum = math.Float64frombits(binary.LittleEndian.Uint64(b[17:]))
+	}
+	n := binary.LittleEndian.Uint64(b[headerSize-8:])
+	b = b[headerSize:]
+
+	if err := validateCompression(compression); err != nil {
+		return err
+	}
+	if uint64(len(b))/16 != n || len(b)%16 != 0 {
+		return fmt.Errorf("tdigest: got %d bytes for %d centroids", len(b), n)
+	}
+
+	centroids := make([]centroid, n)
+	for i := range centroids {
+		centroids[i] = centroid{
+			mean:  math.Float64frombits(binary.LittleEndian.Uint64(b[16*i:])),
+			count: math.Float64frombits(binary.LittleEndian.Uint64(b[16*i+8:])),
+		}
+	}
+	if err := validateCentroids(centroids, count); err != nil {
+		return err
+	}
+
+	d.reset()
+	d.compression = compression
+	d.setCentroids(centroids)
+	if version != 1 {
+		d.sum = sum
+	}
+	return nil
+}
+
+// validateCompression returns an error if compression is not positive and
+// finite.
+func validateCompression(compression float64) error {
+	if !(compression > 0) || math.IsInf(compression, 1) {
+		return fmt.Errorf("tdigest: compression must be positive and finite, got %v", compression)
+	}
+	return nil
+}
+
+// validateCentroids returns an error if centroids are not sorted by increasing
+// mean, have non-positive counts, or don't sum to count.
+func validateCentroids(centroids []centroid, count float64) error {
+	var total float64
+	for i, c := range centroids {
+		var prev *centroid
+		if i > 0 {
+			prev = &centroids[i-1]
+		}
+		if err := validateCentroid(i, &c, prev); err != nil {
+			return err
+		}
+		total += c.count
+	}
+	return validateCount(count, total)
+}
+
+// validateCentroid returns an error if c, the centroid at index i, has a
+// non-positive or non-finite count, a non-finite mean, or a mean less than
+// prev's. prev is nil for the first centroid.
+func validateCentroid(i int, c, prev *centroid) error {
+	if !(c.count > 0) {
+		return fmt.Errorf("tdigest: centroid %d has non-positive count %v", i, c.count)
+	}
+	if !isValid(c.mean) || !isValid(c.count) {
+		return fmt.Errorf("tdigest: centroid %d has non-finite mean %v or count %v", i, c.mean, c.count)
+	}
+	if prev != nil && c.mean < prev.mean {
+		return fmt.Errorf("tdigest: centroid %d with mean %v is less than previous mean %v",
+			i, c.mean, prev.mean)
+	}
+	return nil
+}
+
+// validateCount returns an error if the centroid counts, summing to total,
+// don't match count.
+func validateCount(count, total float64) error {
+	// Allow for rounding in digests built from weighted centroids.
+	if math.Abs(total-count) > 1e-9*math.Max(1, count) {
+		return fmt.Errorf("tdigest: got count %v, but centroid counts sum to %v", count, total)
+	}
+	return nil
+}
+
+// Serialize is MarshalBinary for callers which don't need to check an error.
+// MarshalBinary never fails, so neither does Serialize.
+func (d *TDigest) Serialize() []byte {
+	b, err := d.MarshalBinary()
+	if err != nil {
+		panic(fmt.Sprintf("tdigest: marshalling TDigest: %v", err))
+	}
+	return b
+}
+
+// Deserialize replaces the TDigest with one encoded by Serialize or
+// MarshalBinary.
+func (d *TDigest) Deserialize(b []byte) error {
+	return d.UnmarshalBinary(b)
+}
+
+// Must returns the TDigest encoded in b, and panics if b is malformed.
+//
+// Must is for tests and fixtures whose bytes are known to be valid, e.g.
+// var testDigest = tdigest.Must(savedBytes). Use Deserialize for bytes read
+// from disk or the network.
+func Must(b []byte) *TDigest {
+	d := &TDigest{}
+	if err := d.UnmarshalBinary(b); err != nil {
+		panic(err.Error())
+	}
+	return d
+}
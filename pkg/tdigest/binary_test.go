@@ -0,0 +1,256 @@
+package tdigest_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_Serialize(t *testing.T) {
+	digest := newUniformRange(20, 10000, 0, 1, 1)
+
+	want, err := digest.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := digest.Serialize()
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got Serialize() different from MarshalBinary()")
+	}
+
+	unmarshalled := tdigest.New(tdigest.WithCompression(100))
+	if err = unmarshalled.UnmarshalBinary(want); err != nil {
+		t.Fatal(err)
+	}
+	deserialized := tdigest.New(tdigest.WithCompression(100))
+	if err = deserialized.Deserialize(got); err != nil {
+		t.Fatal(err)
+	}
+	must := tdigest.Must(got)
+
+	for _, q := range []float64{0.01, 0.5, 0.99} {
+		want := mustFloat(unmarshalled.Quantile(q))
+		if got := mustFloat(deserialized.Quantile(q)); got != want {
+			t.Errorf("got Deserialize Quantile(%v) = %v, want %v", q, got, want)
+		}
+		if got := mustFloat(must.Quantile(q)); got != want {
+			t.Errorf("got Must Quantile(%v) = %v, want %v", q, got, want)
+		}
+		if got := mustFloat(digest.Quantile(q)); got != want {
+			t.Errorf("got original Quantile(%v) = %v, want %v", q, got, want)
+		}
+	}
+}
+
+func TestTDigest_Deserialize_Malformed(t *testing.T) {
+	b := newUniformRange(20, 1000, 0, 1, 1).Serialize()
+
+	tcs := []struct {
+		name string
+		b    []byte
+	}{{
+		name: "empty",
+		b:    nil,
+	}, {
+		name: "truncated header",
+		b:    b[:10],
+	}, {
+		name: "truncated centroid",
+		b:    b[:len(b)-1],
+	}, {
+		name: "unknown version",
+		b:    append([]byte{3}, b[1:]...),
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tdigest.New(tdigest.WithCompression(100)).Deserialize(tc.b); err == nil {
+				t.Error("got no error")
+			}
+		})
+	}
+}
+
+func TestMust_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic")
+		}
+	}()
+	tdigest.Must([]byte{1})
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	digest := newUniformRange(20, 10000, 0, 1, 1)
+
+	b, err := digest.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := tdigest.New(tdigest.WithCompression(100))
+	if err = got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.Equal(digest) {
+		t.Errorf("got centroids %v, want %v", got, digest)
+	}
+	for i := 0; i <= 100; i++ {
+		q := float64(i) / 100
+		if got, want := mustFloat(got.Quantile(q)), mustFloat(digest.Quantile(q)); got != want {
+			t.Errorf("got Quantile(%v) = %v, want %v", q, got, want)
+		}
+	}
+
+	// The unmarshalled digest keeps the original compression.
+	got.Add(0.5)
+	digest.Add(0.5)
+	if !got.Equal(digest) {
+		t.Error("got different centroids after adding to unmarshalled digest")
+	}
+}
+
+func TestTDigest_UnmarshalBinary_Version1(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(1))
+	digest.Add(1)
+	digest.Add(2)
+	b, err := digest.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Version 1 has no sum after the count.
+	v1 := append([]byte{1}, b[1:17]...)
+	v1 = append(v1, b[25:]...)
+
+	got := tdigest.New()
+	if err = got.UnmarshalBinary(v1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = got.ReadFrom(bytes.NewReader(v1)); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(digest) {
+		t.Errorf("got centroids %v, want %v", got, digest)
+	}
+	if got, want := mustFloat(got.Mean()), mustFloat(digest.Mean()); got != want {
+		t.Errorf("got Mean() = %v, want %v", got, want)
+	}
+}
+
+func TestTDigest_UnmarshalBinary_Invalid(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(1))
+	digest.Add(1)
+	digest.Add(2)
+	b, err := digest.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Swap the two centroids.
+	unsorted := append([]byte{}, b[:33]...)
+	unsorted = append(unsorted, b[49:]...)
+	unsorted = append(unsorted, b[33:49]...)
+
+	// Double the total count.
+	miscounted := append([]byte{}, b...)
+	miscounted[16]++
+
+	for name, b := range map[string][]byte{"unsorted": unsorted, "miscounted": miscounted} {
+		if err := tdigest.New(tdigest.WithCompression(1)).UnmarshalBinary(b); err == nil {
+			t.Errorf("got no error unmarshalling %s centroids", name)
+		}
+	}
+}
+
+func BenchmarkTDigest_MarshalBinary(b *testing.B) {
+	digest := newUniformRange(20, 10000, 0, 1, 1)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = digest.MarshalBinary()
+	}
+}
+
+func BenchmarkTDigest_UnmarshalBinary(b *testing.B) {
+	bytes := newUniformRange(20, 10000, 0, 1, 1).Serialize()
+	digest := tdigest.New(tdigest.WithCompression(20))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = digest.UnmarshalBinary(bytes)
+	}
+}
+
+func TestTDigest_Unmarshal_InvalidCompression(t *testing.T) {
+	digest := newUniformRange(20, 1000, 0, 1, 1)
+	binaryBytes := digest.Serialize()
+	compressedBytes, err := digest.MarshalCompressed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	protoBytes, err := digest.MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonBytes, err := digest.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	textBytes, err := digest.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// withCompression returns a copy of b with the compression at offset
+	// replaced.
+	withCompression := func(b []byte, offset int, compression float64) []byte {
+		b = append([]byte{}, b...)
+		binary.LittleEndian.PutUint64(b[offset:], math.Float64bits(compression))
+		return b
+	}
+
+	for _, compression := range []float64{math.NaN(), 0, -20, math.Inf(1)} {
+		decoders := map[string]func(d *tdigest.TDigest) error{
+			"UnmarshalBinary": func(d *tdigest.TDigest) error {
+				return d.UnmarshalBinary(withCompression(binaryBytes, 1, compression))
+			},
+			"ReadFrom": func(d *tdigest.TDigest) error {
+				_, err := d.ReadFrom(bytes.NewReader(withCompression(binaryBytes, 1, compression)))
+				return err
+			},
+			"UnmarshalCompressed": func(d *tdigest.TDigest) error {
+				return d.UnmarshalCompressed(withCompression(compressedBytes, 4, compression))
+			},
+			// The compression is the first field, after a one byte tag.
+			"UnmarshalProto": func(d *tdigest.TDigest) error {
+				return d.UnmarshalProto(withCompression(protoBytes, 1, compression))
+			},
+			"UnmarshalText": func(d *tdigest.TDigest) error {
+				rest := textBytes[bytes.IndexByte(textBytes, ';'):]
+				return d.UnmarshalText(append([]byte(fmt.Sprint(compression)), rest...))
+			},
+		}
+		if !math.IsNaN(compression) && !math.IsInf(compression, 0) {
+			decoders["UnmarshalJSON"] = func(d *tdigest.TDigest) error {
+				return d.UnmarshalJSON(bytes.Replace(jsonBytes,
+					[]byte(`"compression":20`), []byte(fmt.Sprintf(`"compression":%v`, compression)), 1))
+			}
+		}
+
+		for name, decode := range decoders {
+			got := newUniformRange(10, 100, 5, 6, 2)
+			want := got.Clone()
+			if err := decode(got); err == nil {
+				t.Errorf("got no error from %s with compression %v", name, compression)
+			}
+			if !got.Equal(want) {
+				t.Errorf("got TDigest changed by %s with compression %v", name, compression)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package tdigest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Builder collects observations from several sources and builds a TDigest of
+// all of them at once. It is more accurate than adding the observations to a
+// TDigest one at a time, and is appropriate when all of them are available
+// before the first query.
+//
+// The methods return the Builder so calls can be chained. Invalid values are
+// not added, and Build reports the first of them.
+type Builder struct {
+	compression float64
+
+	// vals are the values added with Add and AddAll. Sorting them alone is
+	// much faster than sorting them as centroids.
+	vals []float64
+	// centroids are the weighted values and the centroids of merged digests.
+	centroids []centroid
+	min, max  float64
+	sum       float64
+
+	err error
+}
+
+// NewBuilder returns a Builder of TDigests with the given compression. It
+// panics if compression is not positive and finite.
+func NewBuilder(compression float64) *Builder {
+	if !(compression > 0) || math.IsInf(compression, 1) {
+		panic(fmt.Sprintf("tdigest: compression must be positive and finite, got %v", compression))
+	}
+	b := &Builder{compression: compression}
+	b.reset()
+	return b
+}
+
+// Compression returns the compression of the TDigests the Builder builds. A
+// zero compression means DefaultCompression, as for TDigest.
+func (b *Builder) Compression() float64 {
+	if b.compression == 0 {
+		return DefaultCompression
+	}
+	return b.compression
+}
+
+// reset empties the Builder.
+func (b *Builder) reset() {
+	b.vals = nil
+	b.centroids = nil
+	b.min, b.max = math.Inf(1), math.Inf(-1)
+	b.sum = 0
+	b.err = nil
+}
+
+// Add adds val. If val is NaN or infinite, it is skipped and Build returns
+// ErrInvalidValue.
+func (b *Builder) Add(val float64) *Builder {
+	if !isValid(val) {
+		b.fail(ErrInvalidValue)
+		return b
+	}
+	b.add(val)
+	return b
+}
+
+// AddAll adds each of vals. As for TDigest.AddAll, if any of vals is NaN or
+// infinite, none of them are added and Build returns ErrInvalidValue.
+func (b *Builder) AddAll(vals []float64) *Builder {
+	for _, val := range vals {
+		if !isValid(val) {
+			b.fail(ErrInvalidValue)
+			return b
+		}
+	}
+	for _, val := range vals {
+		b.add(val)
+	}
+	return b
+}
+
+// AddWeighted adds count observations of val. If val is NaN or infinite, it is
+// skipped and Build returns ErrInvalidValue. Panics if count is not positive
+// and finite.
+func (b *Builder) AddWeighted(val, count float64) *Builder {
+	if !(count > 0) || math.IsInf(count, 1) {
+		panic(fmt.Sprintf("tdigest: AddWeighted count must be positive and finite, got %v", count))
+	}
+	if !isValid(val) {
+		b.fail(ErrInvalidValue)
+		return b
+	}
+	b.centroids = append(b.centroids, centroid{mean: val, count: count})
+	b.sum += val * count
+	b.min, b.max = math.Min(b.min, val), math.Max(b.max, val)
+	return b
+}
+
+// Merge adds the observations summarized by d. Nil and empty digests are
+// skipped, and d is not modified.
+func (b *Builder) Merge(d *TDigest) *Builder {
+	if d == nil || d.nCentroids == 0 {
+		return b
+	}
+	for _, c := range d.centroids {
+		b.centroids = append(b.centroids, centroid{mean: c.mean, count: c.count})
+	}
+	b.sum += d.sum
+	b.min, b.max = math.Min(b.min, d.min), math.Max(b.max, d.max)
+	return b
+}
+
+// add buffers val.
+func (b *Builder) add(val float64) {
+	b.vals = append(b.vals, val)
+	b.sum += val
+	b.min, b.max = math.Min(b.min, val), math.Max(b.max, val)
+}
+
+// fail records err if it is the first error.
+func (b *Builder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Build returns a TDigest of everything added to the Builder, and the first
+// error from adding values, if any. The Builder is empty afterwards, so it can
+// be reused.
+//
+// The buffered observations are sorted once, then merged in a single pass as
+// by Recompress, so each centroid is within the limit Add gives it and the
+// TDigest can replace one built with Add at the same compression. The minimum
+// and maximum are exact.
+func (b *Builder) Build() (*TDigest, error) {
+	result := New(WithCompression(b.Compression()))
+	vals, weighted, min, max, sum, err := b.vals, b.centroids, b.min, b.max, b.sum, b.err
+	b.reset()
+	if len(vals)+len(weighted) == 0 {
+		return result, err
+	}
+
+	sort.Float64s(vals)
+	sort.Slice(weighted, func(i, j int) bool {
+		return weighted[i].mean < weighted[j].mean
+	})
+	centroids := make([]centroid, 0, len(vals)+len(weighted))
+	count := float64(len(vals))
+	i := 0
+	for _, c := range weighted {
+		for ; i < len(vals) && vals[i] < c.mean; i++ {
+			centroids = append(centroids, centroid{mean: vals[i], count: 1})
+		}
+		centroids = append(centroids, c)
+		count += c.count
+	}
+	for ; i < len(vals); i++ {
+		centroids = append(centroids, centroid{mean: vals[i], count: 1})
+	}
+
+	centroids = result.compressCentroids(centroids, count)
+	result.setCentroids(centroids)
+	result.min, result.max = min, max
+	result.sum = sum
+	return result, err
+}
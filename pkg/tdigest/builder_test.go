@@ -0,0 +1,179 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestBuilder(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var vals []float64
+	next := func() float64 {
+		val := r.NormFloat64()
+		vals = append(vals, val)
+		return val
+	}
+
+	batch := make([]float64, 10000)
+	for i := range batch {
+		batch[i] = next()
+	}
+	shard := tdigest.New(tdigest.WithCompression(20))
+	for i := 0; i < 10000; i++ {
+		shard.Add(next())
+	}
+	single, weighted := next(), next()
+	for i := 0; i < 9; i++ {
+		vals = append(vals, weighted)
+	}
+	sort.Float64s(vals)
+
+	b := tdigest.NewBuilder(20)
+	digest, err := b.AddAll(batch).Merge(shard).Merge(nil).Add(single).AddWeighted(weighted, 10).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = digest.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := digest.LoadFactor(); got > 1 {
+		t.Errorf("got LoadFactor() = %v, want at most 1", got)
+	}
+	if got := digest.Count(); got != float64(len(vals)) {
+		t.Errorf("got Count() = %v, want %v", got, len(vals))
+	}
+	if got := mustFloat(digest.Min()); got != vals[0] {
+		t.Errorf("got Min() = %v, want %v", got, vals[0])
+	}
+	if got := mustFloat(digest.Max()); got != vals[len(vals)-1] {
+		t.Errorf("got Max() = %v, want %v", got, vals[len(vals)-1])
+	}
+
+	// The Builder is empty after Build.
+	empty, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := empty.Count(); got != 0 {
+		t.Errorf("got Count() = %v building again, want 0", got)
+	}
+}
+
+func TestBuilder_Accuracy(t *testing.T) {
+	// A Builder uses the limits Add does, so its TDigest can replace one built
+	// with Add at the same compression.
+	for _, compression := range []float64{10, 20, 100} {
+		_, vals := newUncompressed(compression, 100000)
+		added := tdigest.New(tdigest.WithCompression(compression))
+		added.AddAll(vals)
+		built, err := tdigest.NewBuilder(compression).AddAll(vals).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := built.CentroidCount(), added.CentroidCount(); got > want {
+			t.Errorf("got CentroidCount() = %v at compression %v, want at most %v as with Add", got, compression, want)
+		}
+		sort.Float64s(vals)
+		for _, q := range []float64{0.001, 0.01, 0.5, 0.99, 0.999} {
+			got := rankError(vals, q, mustFloat(built.Quantile(q)))
+			want := rankError(vals, q, mustFloat(added.Quantile(q)))
+			if got > 2*want+0.0005 {
+				t.Errorf("got Quantile(%v) rank error %v at compression %v, want about %v as with Add",
+					q, got, compression, want)
+			}
+		}
+	}
+}
+
+func TestBuilder_Invalid(t *testing.T) {
+	tcs := []struct {
+		name  string
+		build func(b *tdigest.Builder) *tdigest.Builder
+		count float64
+	}{{
+		name: "Add",
+		build: func(b *tdigest.Builder) *tdigest.Builder {
+			return b.Add(1).Add(math.NaN()).Add(2)
+		},
+		count: 2,
+	}, {
+		name: "AddAll",
+		build: func(b *tdigest.Builder) *tdigest.Builder {
+			return b.Add(1).AddAll([]float64{2, math.Inf(1), 3})
+		},
+		count: 1,
+	}, {
+		name: "AddWeighted",
+		build: func(b *tdigest.Builder) *tdigest.Builder {
+			return b.AddWeighted(math.Inf(-1), 5).AddWeighted(1, 5)
+		},
+		count: 5,
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			b := tdigest.NewBuilder(20)
+			digest, err := tc.build(b).Build()
+			if err != tdigest.ErrInvalidValue {
+				t.Errorf("got error %v, want %v", err, tdigest.ErrInvalidValue)
+			}
+			if got := digest.Count(); got != tc.count {
+				t.Errorf("got Count() = %v, want %v", got, tc.count)
+			}
+
+			// The error is cleared by Build.
+			if _, err = b.Add(1).Build(); err != nil {
+				t.Errorf("got error %v building again, want nil", err)
+			}
+		})
+	}
+}
+
+// BenchmarkBuilder compares building a TDigest of normally distributed values
+// with a Builder and with Add, reporting the largest difference between the
+// requested and actual rank of a range of quantiles.
+func BenchmarkBuilder(b *testing.B) {
+	const compression = 100
+
+	r := rand.New(rand.NewSource(1))
+	vals := make([]float64, 1000000)
+	for i := range vals {
+		vals[i] = r.NormFloat64()
+	}
+	sorted := append([]float64(nil), vals...)
+	sort.Float64s(sorted)
+
+	reportError := func(b *testing.B, digest *tdigest.TDigest) {
+		var maxErr float64
+		for _, q := range []float64{0.001, 0.01, 0.1, 0.5, 0.9, 0.99, 0.999} {
+			got := mustFloat(digest.Quantile(q))
+			rank := float64(sort.SearchFloat64s(sorted, got)) / float64(len(sorted))
+			maxErr = math.Max(maxErr, math.Abs(rank-q))
+		}
+		b.ReportMetric(maxErr, "max-rank-err")
+	}
+
+	b.Run("Builder", func(b *testing.B) {
+		var digest *tdigest.TDigest
+		for i := 0; i < b.N; i++ {
+			digest, _ = tdigest.NewBuilder(compression).AddAll(vals).Build()
+		}
+		reportError(b, digest)
+	})
+	b.Run("Add", func(b *testing.B) {
+		var digest *tdigest.TDigest
+		for i := 0; i < b.N; i++ {
+			digest = tdigest.New(tdigest.WithCompression(compression))
+			for _, val := range vals {
+				digest.Add(val)
+			}
+		}
+		reportError(b, digest)
+	})
+}
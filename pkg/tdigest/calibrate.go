@@ -0,0 +1,75 @@
+package tdigest
+
+import "sort"
+
+// Calibrate returns a copy of the TDigest adjusted so that Quantile(q) is
+// approximately trueQuantiles[q] for each calibration quantile q. For example,
+// trueQuantiles may be measured with a slower exact algorithm to correct
+// systematic bias in the TDigest.
+//
+// Centroid means are mapped by the piecewise-affine function through the
+// points (d.Quantile(q), trueQuantiles[q]). Means below the lowest or above the
+// highest calibration point are shifted by that point's correction.
+//
+// Calibration changes the statistical meaning of the TDigest: the result no
+// longer summarizes the observations which were added, but the observations
+// adjusted toward the calibration values.
+func (d *TDigest) Calibrate(trueQuantiles map[float64]float64) *TDigest {
+	if d.nCentroids == 0 || len(trueQuantiles) == 0 {
+		return d.Clone()
+	}
+
+	qs := make([]float64, 0, len(trueQuantiles))
+	for q := range trueQuantiles {
+		qs = append(qs, q)
+	}
+	sort.Float64s(qs)
+
+	// from[i] is the estimated value that should be mapped to to[i].
+	from := make([]float64, 0, len(qs))
+	to := make([]float64, 0, len(qs))
+	for _, q := range qs {
+		v := d.quantile(q)
+		if len(from) > 0 && v <= from[len(from)-1] {
+			// Quantiles of a flat region of the distribution estimate the same
+			// value, so the mapping can only use the first.
+			continue
+		}
+		from = append(from, v)
+		to = append(to, trueQuantiles[q])
+	}
+
+	result := d.Clone()
+	for i := range result.centroids {
+		c := &result.centroids[i]
+		c.mean = calibrate(c.mean, from, to)
+	}
+
+	// If the calibration values aren't increasing, centroids may now be out of
+	// order.
+	sort.SliceStable(result.centroids, func(i, j int) bool {
+		return result.centroids[i].mean < result.centroids[j].mean
+	})
+	for i := range result.centroids {
+		result.centroids[i].maxCount = 0
+	}
+	result.setCentroids(result.centroids)
+	return result
+}
+
+// calibrate maps val by the piecewise-affine function through (from[i], to[i]).
+// from must be strictly increasing.
+func calibrate(val float64, from, to []float64) float64 {
+	n := len(from)
+	switch {
+	case val <= from[0]:
+		return val + to[0] - from[0]
+	case val >= from[n-1]:
+		return val + to[n-1] - from[n-1]
+	}
+
+	i := sort.SearchFloat64s(from, val)
+	// from[i-1] < val <= from[i]
+	slope := (to[i] - to[i-1]) / (from[i] - from[i-1])
+	return to[i-1] + slope*(val-from[i-1])
+}
@@ -0,0 +1,49 @@
+package tdigest_test
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTDigest_Calibrate(t *testing.T) {
+	digest := newUniformRange(20, 100000, 0, 1, 1)
+
+	trueQuantiles := map[float64]float64{
+		0.1: 0.2,
+		0.5: 0.6,
+		0.9: 0.95,
+	}
+	calibrated := digest.Calibrate(trueQuantiles)
+
+	for q, want := range trueQuantiles {
+		if got := mustFloat(calibrated.Quantile(q)); math.Abs(got-want) > 0.005 {
+			t.Errorf("Quantile(%v): got %v, want %v", q, got, want)
+		}
+	}
+
+	// Between calibration points, estimates are smooth and monotone.
+	prev := mustFloat(calibrated.Quantile(0))
+	for i := 1; i <= 1000; i++ {
+		q := float64(i) / 1000
+		got := mustFloat(calibrated.Quantile(q))
+		if got < prev {
+			t.Errorf("got Quantile(%v) = %v, less than previous %v", q, got, prev)
+		}
+		if got-prev > 0.01 {
+			t.Errorf("got Quantile(%v) = %v, jumped from %v", q, got, prev)
+		}
+		prev = got
+	}
+
+	// The original is unchanged.
+	if got := mustFloat(digest.Quantile(0.5)); math.Abs(got-0.5) > 0.01 {
+		t.Errorf("got original Quantile(0.5) = %v, want approximately 0.5", got)
+	}
+}
+
+func TestTDigest_Calibrate_Empty(t *testing.T) {
+	digest := newUniformRange(20, 1000, 0, 1, 1)
+	if got, want := digest.Calibrate(nil).String(), digest.String(); got != want {
+		t.Errorf("got Calibrate(nil) = %v, want %v", got, want)
+	}
+}
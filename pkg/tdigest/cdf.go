@@ -0,0 +1,112 @@
+package tdigest
+
+import (
+	"math"
+	"sort"
+)
+
+// CDF returns the estimated fraction of observations less than or equal to x,
+// or ErrEmptyDigest if the TDigest is empty.
+//
+// Each centroid's mean is placed at the middle of its observations' ranks, and
+// values between neighboring means are linearly interpolated. Values below the
+// lowest mean return 0 and values at or above the highest mean return 1.
+func (d *TDigest) CDF(x float64) (float64, error) {
+	if d.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+	return d.cdf(x), nil
+}
+
+// cdf is CDF, but returns NaN if the TDigest is empty.
+func (d *TDigest) cdf(x float64) float64 {
+	n := len(d.centroids)
+	switch {
+	case n == 0:
+		return math.NaN()
+	case x < d.centroids[0].mean:
+		return 0
+	case x >= d.centroids[n-1].mean:
+		return 1
+	}
+
+	// Find the neighboring centroids with means around x, accumulating the
+	// count of observations before them.
+	var total float64
+	for i, c := range d.centroids[:n-1] {
+		next := d.centroids[i+1]
+		if x < next.mean {
+			middle := total + c.count/2
+			nextMiddle := total + c.count + next.count/2
+			rank := middle + (x-c.mean)/(next.mean-c.mean)*(nextMiddle-middle)
+			return rank / d.count
+		}
+		total += c.count
+	}
+
+	// Unreachable since x is below the last centroid's mean.
+	return 1
+}
+
+// QuantileRank returns the estimated number of observations less than or equal
+// to x, between 0 and the total count. QuantileRank of an empty TDigest is 0.
+func (d *TDigest) QuantileRank(x float64) float64 {
+	if len(d.centroids) == 0 {
+		return 0
+	}
+	return d.cdf(x) * d.count
+}
+
+// CountBelow returns the estimated number of observations less than or equal
+// to x, as QuantileRank does. CountBelow of an empty TDigest is 0.
+func (d *TDigest) CountBelow(x float64) float64 {
+	return d.QuantileRank(x)
+}
+
+// CountAbove returns the estimated number of observations greater than x, such
+// as requests slower than a latency threshold. CountAbove of an empty TDigest
+// is 0.
+func (d *TDigest) CountAbove(x float64) float64 {
+	return d.count - d.CountBelow(x)
+}
+
+// ConditionalCount returns the estimated number of observations between a and
+// b inclusive, CountBelow(b) - CountBelow(a). It returns NaN if a > b.
+// ConditionalCount of an empty TDigest is 0.
+func (d *TDigest) ConditionalCount(a, b float64) float64 {
+	if !(a <= b) {
+		return math.NaN()
+	}
+	return d.CountBelow(b) - d.CountBelow(a)
+}
+
+// PDF returns the estimated probability density at x, or ErrEmptyDigest if the
+// TDigest is empty. It is 0 outside the observed values.
+//
+// The density is the slope of CDF across x, (CDF(x+delta) - CDF(x-delta)) /
+// (2*delta), where delta is the gap between the centroid means around x. CDF
+// is linear between neighboring means, but the gaps between them are noisy, so
+// this averages over the neighboring gaps too.
+func (d *TDigest) PDF(x float64) (float64, error) {
+	n := len(d.centroids)
+	switch {
+	case n == 0:
+		return math.NaN(), ErrEmptyDigest
+	case n == 1 || x < d.min || x > d.max:
+		// A single centroid has no gaps to spread its observations over.
+		return 0, nil
+	}
+
+	// The first centroid with a mean above x, so x is in the gap before it.
+	// Beyond the outermost means, use the outermost gaps.
+	idx := sort.Search(n, func(i int) bool {
+		return d.centroids[i].mean > x
+	})
+	if idx == 0 {
+		idx = 1
+	} else if idx == n {
+		idx = n - 1
+	}
+	delta := d.centroids[idx].mean - d.centroids[idx-1].mean
+	return (d.cdf(x+delta) - d.cdf(x-delta)) / (2 * delta), nil
+}
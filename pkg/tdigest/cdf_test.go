@@ -0,0 +1,143 @@
+package tdigest_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_CDF(t *testing.T) {
+	digest := newUniformRange(20, 100000, 0, 1, 1)
+
+	prev := 0.0
+	for i := 0; i <= 100; i++ {
+		x := float64(i) / 100
+		got := mustFloat(digest.CDF(x))
+		if math.Abs(got-x) > 0.02 {
+			t.Errorf("got CDF(%v) = %v, want %v +/- 0.02", x, got, x)
+		}
+		if got < prev {
+			t.Errorf("got CDF(%v) = %v, less than previous %v", x, got, prev)
+		}
+		prev = got
+	}
+
+	for _, q := range []float64{0.1, 0.5, 0.9} {
+		if got := mustFloat(digest.CDF(mustFloat(digest.Quantile(q)))); math.Abs(got-q) > 0.01 {
+			t.Errorf("got CDF(Quantile(%v)) = %v", q, got)
+		}
+	}
+}
+
+func TestTDigest_CDF_OutOfRange(t *testing.T) {
+	digest := newUniformRange(20, 1000, 0, 1, 1)
+
+	if got := mustFloat(digest.CDF(-1)); got != 0 {
+		t.Errorf("got CDF(-1) = %v, want 0", got)
+	}
+	if got := mustFloat(digest.CDF(2)); got != 1 {
+		t.Errorf("got CDF(2) = %v, want 1", got)
+	}
+
+	single := tdigest.New(tdigest.WithCompression(20))
+	single.Add(5)
+	if got := mustFloat(single.CDF(4)); got != 0 {
+		t.Errorf("got CDF(4) = %v with single value 5, want 0", got)
+	}
+	if got := mustFloat(single.CDF(5)); got != 1 {
+		t.Errorf("got CDF(5) = %v with single value 5, want 1", got)
+	}
+
+	if got, err := tdigest.New(tdigest.WithCompression(20)).CDF(0); !math.IsNaN(got) || err != tdigest.ErrEmptyDigest {
+		t.Errorf("got CDF(0) = %v, %v for empty digest, want NaN, ErrEmptyDigest", got, err)
+	}
+}
+
+func TestTDigest_CDF_Exact(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(1))
+	for _, v := range []float64{1, 2, 3, 4} {
+		digest.Add(v)
+	}
+
+	// Each centroid is one observation, placed at the middle of its rank:
+	// 0.5, 1.5, 2.5, 3.5 of 4.
+	for x, want := range map[float64]float64{1: 0.125, 1.5: 0.25, 2: 0.375, 3.5: 0.75, 4: 1} {
+		if got := mustFloat(digest.CDF(x)); got != want {
+			t.Errorf("got CDF(%v) = %v, want %v", x, got, want)
+		}
+	}
+}
+
+func TestTDigest_QuantileRank(t *testing.T) {
+	digest := newUniformRange(20, 100000, 0, 1, 1)
+	count := digest.Count()
+
+	for _, q := range []float64{0.01, 0.1, 0.5, 0.9, 0.99} {
+		got := digest.QuantileRank(mustFloat(digest.Quantile(q)))
+		if want := q * count; math.Abs(got-want) > 0.01*count {
+			t.Errorf("got QuantileRank(Quantile(%v)) = %v, want %v +/- %v", q, got, want, 0.01*count)
+		}
+	}
+
+	if got := digest.QuantileRank(-1); got != 0 {
+		t.Errorf("got QuantileRank(-1) = %v, want 0", got)
+	}
+	if got := digest.QuantileRank(2); got != count {
+		t.Errorf("got QuantileRank(2) = %v, want %v", got, count)
+	}
+	if got := tdigest.New(tdigest.WithCompression(20)).QuantileRank(0); got != 0 {
+		t.Errorf("got QuantileRank(0) = %v for empty digest, want 0", got)
+	}
+}
+
+func TestTDigest_CountBelow_CountAbove(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(20))
+	for i := 1; i <= 1001; i++ {
+		digest.Add(float64(i))
+	}
+	count := digest.Count()
+
+	if got := digest.CountBelow(501); math.Abs(got-count/2) > 0.01*count {
+		t.Errorf("got CountBelow(501) = %v, want %v +/- %v", got, count/2, 0.01*count)
+	}
+	if got := digest.CountBelow(1001); got != count {
+		t.Errorf("got CountBelow(1001) = %v, want %v", got, count)
+	}
+	if got := digest.CountAbove(1001); got != 0 {
+		t.Errorf("got CountAbove(1001) = %v, want 0", got)
+	}
+	if got := digest.CountAbove(0); got != count {
+		t.Errorf("got CountAbove(0) = %v, want %v", got, count)
+	}
+	for _, x := range []float64{10, 500, 990} {
+		if got := digest.CountBelow(x) + digest.CountAbove(x); got != count {
+			t.Errorf("got CountBelow(%v) + CountAbove(%v) = %v, want %v", x, x, got, count)
+		}
+	}
+
+	empty := tdigest.New()
+	if below, above := empty.CountBelow(1), empty.CountAbove(1); below != 0 || above != 0 {
+		t.Errorf("got CountBelow(1) = %v and CountAbove(1) = %v for empty digest, want 0", below, above)
+	}
+}
+
+func TestTDigest_PDF(t *testing.T) {
+	digest := newUniformRange(20, 100000, 0, 1, 1)
+
+	// The density of the uniform distribution is 1 everywhere inside it.
+	for x := 0.1; x < 0.9; x += 0.01 {
+		if got := mustFloat(digest.PDF(x)); math.Abs(got-1) > 0.1 {
+			t.Errorf("got PDF(%v) = %v, want 1 +/- 0.1", x, got)
+		}
+	}
+
+	for _, x := range []float64{-1, 2} {
+		if got := mustFloat(digest.PDF(x)); got != 0 {
+			t.Errorf("got PDF(%v) = %v outside the observations, want 0", x, got)
+		}
+	}
+	if got, err := tdigest.New().PDF(0.5); err != tdigest.ErrEmptyDigest || !math.IsNaN(got) {
+		t.Errorf("got PDF(0.5) = %v, %v on empty TDigest, want NaN, %v", got, err, tdigest.ErrEmptyDigest)
+	}
+}
@@ -0,0 +1,59 @@
+package tdigest
+
+// CentroidData is a copy of the state of a single centroid.
+type CentroidData struct {
+	Mean  float64
+	Count float64
+}
+
+// Centroids returns a copy of the TDigest's centroids, sorted by increasing
+// mean. Changing the result doesn't affect the TDigest.
+func (d *TDigest) Centroids() []CentroidData {
+	result := make([]CentroidData, len(d.centroids))
+	for i, c := range d.centroids {
+		result[i] = CentroidData{Mean: c.mean, Count: c.count}
+	}
+	return result
+}
+
+// ForEach calls fn with the mean and count of each centroid in order of
+// increasing mean, without copying them as Centroids does. If fn returns
+// false, ForEach stops.
+//
+// fn must not modify the TDigest, such as by calling Add or Merge, since that
+// may move the centroids being iterated over.
+func (d *TDigest) ForEach(fn func(mean, count float64) bool) {
+	for i := range d.centroids {
+		c := &d.centroids[i]
+		if !fn(c.mean, c.count) {
+			return
+		}
+	}
+}
+
+// FromCentroids returns a TDigest with the given compression and centroids,
+// such as those returned by Centroids. It returns an error if compression is
+// not positive and finite, or if data is not sorted by increasing mean or has
+// counts which are not positive.
+//
+// The minimum and maximum of the TDigest are the means of its outermost
+// centroids.
+func FromCentroids(compression float64, data []CentroidData) (*TDigest, error) {
+	if err := validateCompression(compression); err != nil {
+		return nil, err
+	}
+
+	centroids := make([]centroid, len(data))
+	var count float64
+	for i, c := range data {
+		centroids[i] = centroid{mean: c.Mean, count: c.Count}
+		count += c.Count
+	}
+	if err := validateCentroids(centroids, count); err != nil {
+		return nil, err
+	}
+
+	d := New(WithCompression(compression))
+	d.setCentroids(centroids)
+	return d, nil
+}
@@ -0,0 +1,128 @@
+package tdigest_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_Centroids(t *testing.T) {
+	digest := newUniformRange(20, 10000, 0, 1, 1)
+
+	data := digest.Centroids()
+	var count float64
+	for i, c := range data {
+		if i > 0 && c.Mean < data[i-1].Mean {
+			t.Errorf("got centroid %d with mean %v less than previous %v", i, c.Mean, data[i-1].Mean)
+		}
+		count += c.Count
+	}
+	if count != digest.Count() {
+		t.Errorf("got centroid counts summing to %v, want %v", count, digest.Count())
+	}
+
+	// Changing the copy doesn't change the TDigest.
+	want := digest.String()
+	data[0].Mean = -100
+	data[0].Count = 1e6
+	if got := digest.String(); got != want {
+		t.Error("got TDigest changed after changing Centroids()")
+	}
+}
+
+func TestTDigest_ForEach(t *testing.T) {
+	digest := newUniformRange(20, 10000, 0, 1, 1)
+	want := digest.Centroids()
+
+	var got []tdigest.CentroidData
+	digest.ForEach(func(mean, count float64) bool {
+		got = append(got, tdigest.CentroidData{Mean: mean, Count: count})
+		return true
+	})
+	if len(got) != len(want) {
+		t.Fatalf("got %d centroids, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("got centroid %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	// Returning false stops early.
+	n := 0
+	digest.ForEach(func(mean, count float64) bool {
+		n++
+		return n < 3
+	})
+	if n != 3 {
+		t.Errorf("got %d calls, want 3", n)
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		digest.ForEach(func(mean, count float64) bool { return true })
+	})
+	if allocs != 0 {
+		t.Errorf("got %v allocations, want 0", allocs)
+	}
+}
+
+func TestFromCentroids(t *testing.T) {
+	digest := newUniformRange(20, 10000, 0, 1, 1)
+
+	got, err := tdigest.FromCentroids(20, digest.Centroids())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != digest.String() {
+		t.Errorf("got centroids %v, want %v", got, digest)
+	}
+	for _, q := range []float64{0.01, 0.5, 0.99} {
+		if got, want := mustFloat(got.Quantile(q)), mustFloat(digest.Quantile(q)); got != want {
+			t.Errorf("got Quantile(%v) = %v, want %v", q, got, want)
+		}
+	}
+
+	// The TDigest keeps the compression when adding more values.
+	got.Add(0.5)
+	digest.Add(0.5)
+	if got.String() != digest.String() {
+		t.Error("got different centroids after adding to TDigest from centroids")
+	}
+}
+
+func TestFromCentroids_Invalid(t *testing.T) {
+	tcs := []struct {
+		name        string
+		compression float64
+		data        []tdigest.CentroidData
+	}{{
+		name:        "zero compression",
+		compression: 0,
+		data:        []tdigest.CentroidData{{Mean: 1, Count: 1}},
+	}, {
+		name:        "unsorted",
+		compression: 20,
+		data:        []tdigest.CentroidData{{Mean: 2, Count: 1}, {Mean: 1, Count: 1}},
+	}, {
+		name:        "zero count",
+		compression: 20,
+		data:        []tdigest.CentroidData{{Mean: 1, Count: 0}},
+	}, {
+		name:        "negative count",
+		compression: 20,
+		data:        []tdigest.CentroidData{{Mean: 1, Count: -1}},
+	}, {
+		name:        "NaN mean",
+		compression: 20,
+		data:        []tdigest.CentroidData{{Mean: math.NaN(), Count: 1}},
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tdigest.FromCentroids(tc.compression, tc.data); err == nil {
+				t.Error("got no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package tdigest
+
+import "context"
+
+// Accumulate returns a TDigest with DefaultCompression of every value received
+// from vals. It blocks until vals is closed. NaN and infinite values are
+// skipped.
+func Accumulate(vals <-chan float64) *TDigest {
+	d := New()
+	for val := range vals {
+		_ = d.Add(val)
+	}
+	return d
+}
+
+// AccumulateWithContext is like Accumulate, but returns early if ctx is done.
+// In that case, it returns the TDigest of the values received so far and the
+// context's error.
+func AccumulateWithContext(ctx context.Context, vals <-chan float64) (*TDigest, error) {
+	d := New()
+	for {
+		select {
+		case <-ctx.Done():
+			return d, ctx.Err()
+		case val, ok := <-vals:
+			if !ok {
+				return d, nil
+			}
+			_ = d.Add(val)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package tdigest
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAccumulate(t *testing.T) {
+	vals := make(chan float64)
+	go func() {
+		for i := 0; i < 1000; i++ {
+			vals <- float64(i)
+		}
+		close(vals)
+	}()
+
+	d := Accumulate(vals)
+	if d.count != 1000 {
+		t.Errorf("got count %v, want %v", d.count, 1000)
+	}
+	if got := d.quantile(0.5); got < 450 || got > 550 {
+		t.Errorf("got Quantile(0.5) = %v, want approximately 500", got)
+	}
+}
+
+func TestAccumulateWithContext(t *testing.T) {
+	vals := make(chan float64)
+	go func() {
+		for i := 0; i < 1000; i++ {
+			vals <- float64(i)
+		}
+		close(vals)
+	}()
+
+	d, err := AccumulateWithContext(context.Background(), vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.count != 1000 {
+		t.Errorf("got count %v, want %v", d.count, 1000)
+	}
+}
+
+func TestAccumulateWithContext_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	vals := make(chan float64)
+	go func() {
+		for i := 0; i < 10; i++ {
+			vals <- float64(i)
+		}
+		// Never close vals, so only cancellation ends accumulation.
+		cancel()
+	}()
+
+	d, err := AccumulateWithContext(ctx, vals)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+	if d.count != 10 {
+		t.Errorf("got count %v, want %v", d.count, 10)
+	}
+}
@@ -0,0 +1,39 @@
+package tdigest
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// snapshotChecksumSize is the size of the CRC-32 appended to a snapshot.
+const snapshotChecksumSize = 4
+
+var (
+	errTruncatedSnapshot = errors.New("tdigest: truncated snapshot")
+	errSnapshotChecksum  = errors.New("tdigest: snapshot checksum mismatch")
+)
+
+// Snapshot encodes the TDigest for checkpointing, as MarshalBinary followed by
+// its IEEE CRC-32, little-endian. Restore detects snapshots which were
+// truncated or corrupted, for example by a crash while writing them.
+func (d *TDigest) Snapshot() []byte {
+	b := d.Serialize()
+	return binary.LittleEndian.AppendUint32(b, crc32.ChecksumIEEE(b))
+}
+
+// Restore replaces the TDigest with one encoded by Snapshot. If the checksum
+// doesn't match or the snapshot is otherwise invalid, it returns an error and
+// leaves the TDigest unchanged.
+func (d *TDigest) Restore(snapshot []byte) error {
+	if len(snapshot) < snapshotChecksumSize {
+		return errTruncatedSnapshot
+	}
+	n := len(snapshot) - snapshotChecksumSize
+	b, checksum := snapshot[:n], binary.LittleEndian.Uint32(snapshot[n:])
+	if crc32.ChecksumIEEE(b) != checksum {
+		return errSnapshotChecksum
+	}
+	// UnmarshalBinary only changes the TDigest once b is known to be valid.
+	return d.UnmarshalBinary(b)
+}
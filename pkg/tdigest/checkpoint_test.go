@@ -0,0 +1,57 @@
+package tdigest_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_Restore(t *testing.T) {
+	digest := newUniformRange(20, 10000, 0, 1, 1)
+	snapshot := digest.Snapshot()
+
+	got := tdigest.New()
+	if err := got.Restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(digest) {
+		t.Errorf("got centroids %v, want %v", got, digest)
+	}
+	for _, q := range []float64{0.01, 0.5, 0.99} {
+		if got, want := mustFloat(got.Quantile(q)), mustFloat(digest.Quantile(q)); got != want {
+			t.Errorf("got Quantile(%v) = %v, want %v", q, got, want)
+		}
+	}
+}
+
+func TestTDigest_Restore_Corrupt(t *testing.T) {
+	snapshot := newUniformRange(20, 10000, 0, 1, 1).Snapshot()
+
+	// Restoring a bad snapshot leaves the TDigest as it was.
+	current := newUniformRange(20, 1000, 5, 6, 2)
+	want := current.Clone()
+	check := func(name string, b []byte) {
+		t.Helper()
+		if err := current.Restore(b); err == nil {
+			t.Errorf("got no error restoring %s", name)
+		}
+		if !current.Equal(want) {
+			t.Errorf("got TDigest changed by restoring %s", name)
+		}
+	}
+
+	// A crash while writing leaves a prefix of the snapshot.
+	r := rand.New(rand.NewSource(1))
+	check("empty snapshot", nil)
+	for i := 0; i < 100; i++ {
+		n := r.Intn(len(snapshot))
+		check("truncated snapshot", snapshot[:n])
+	}
+
+	for i := 0; i < 100; i++ {
+		corrupt := append([]byte{}, snapshot...)
+		corrupt[r.Intn(len(corrupt))] ^= byte(1 + r.Intn(255))
+		check("corrupted snapshot", corrupt)
+	}
+}
@@ -0,0 +1,152 @@
+package tdigest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// compressedMagic starts every encoding by MarshalCompressed, so it can't be
+// mistaken for the output of MarshalBinary, which starts with a version byte.
+var compressedMagic = []byte("TDZ")
+
+// compressedVersion is the version of the MarshalCompressed format.
+const compressedVersion = 1
+
+// compressedHeaderSize is the size of the magic, version byte, compression,
+// count, and sum.
+const compressedHeaderSize = 3 + 1 + 24
+
+// maxVarintCount is the largest integral count encoded as a varint. Larger
+// counts are encoded as float64s.
+const maxVarintCount = 1 << 52
+
+var errTruncatedCompressed = errors.New("tdigest: truncated compressed TDigest")
+
+// MarshalCompressed encodes the TDigest losslessly, as MarshalBinary does, but
+// usually in about half the space. The encoding is a magic number, a version
+// byte, the compression, count, and sum, the number of centroids, then the
+// centroids.
+//
+// Sorted means are encoded as varint differences between successive means,
+// as integers which sort in the same order as the means. Counts are varints if
+// they are whole numbers, as they are unless weighted or decayed values were
+// added, and float64s otherwise.
+func (d *TDigest) MarshalCompressed() ([]byte, error) {
+	b := make([]byte, 0, compressedHeaderSize+binary.MaxVarintLen64+10*len(d.centroids))
+	b = append(b, compressedMagic...)
+	b = append(b, compressedVersion)
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(d.Compression()))
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(d.count))
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(d.sum))
+	b = binary.AppendUvarint(b, uint64(len(d.centroids)))
+
+	var prev uint64
+	for _, c := range d.centroids {
+		key := orderedBits(c.mean)
+		// Successive keys never decrease, except that -0 may follow 0, so
+		// the wrapped difference is encoded as signed.
+		b = binary.AppendVarint(b, int64(key-prev))
+		prev = key
+
+		// The low bit marks whether the count is a whole number in the
+		// remaining bits, or a float64 follows.
+		if c.count == math.Trunc(c.count) && c.count <= maxVarintCount {
+			b = binary.AppendUvarint(b, uint64(c.count)<<1)
+		} else {
+			b = append(b, 1)
+			b = binary.LittleEndian.AppendUint64(b, math.Float64bits(c.count))
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalCompressed replaces the TDigest with one encoded by
+// MarshalCompressed. If b is invalid, it returns an error and leaves the
+// TDigest unchanged.
+func (d *TDigest) UnmarshalCompressed(b []byte) error {
+	if len(b) < len(compressedMagic) || !bytes.Equal(b[:len(compressedMagic)], compressedMagic) {
+		return errors.New("tdigest: not a compressed TDigest")
+	}
+	if len(b) < compressedHeaderSize {
+		return errTruncatedCompressed
+	}
+	if version := b[len(compressedMagic)]; version != compressedVersion {
+		return fmt.Errorf("tdigest: unknown compressed TDigest version %d", version)
+	}
+	b = b[len(compressedMagic)+1:]
+	compression := math.Float64frombits(binary.LittleEndian.Uint64(b))
+	count := math.Float64frombits(binary.LittleEndian.Uint64(b[8:]))
+	sum := math.Float64frombits(binary.LittleEndian.Uint64(b[16:]))
+	b = b[24:]
+	if err := validateCompression(compression); err != nil {
+		return err
+	}
+
+	n, size := binary.Uvarint(b)
+	// Each centroid takes at least 2 bytes.
+	if size <= 0 || n > uint64(len(b))/2 {
+		return errTruncatedCompressed
+	}
+	b = b[size:]
+
+	centroids := make([]centroid, n)
+	var key uint64
+	for i := range centroids {
+		delta, size := binary.Varint(b)
+		if size <= 0 {
+			return errTruncatedCompressed
+		}
+		b = b[size:]
+		key += uint64(delta)
+		centroids[i].mean = fromOrderedBits(key)
+
+		c, size := binary.Uvarint(b)
+		if size <= 0 {
+			return errTruncatedCompressed
+		}
+		b = b[size:]
+		if c&1 == 0 {
+			centroids[i].count = float64(c >> 1)
+			continue
+		}
+		if len(b) < 8 {
+			return errTruncatedCompressed
+		}
+		centroids[i].count = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		b = b[8:]
+	}
+	if len(b) != 0 {
+		return fmt.Errorf("tdigest: got %d bytes after the centroids of a compressed TDigest", len(b))
+	}
+	if err := validateCentroids(centroids, count); err != nil {
+		return err
+	}
+
+	d.reset()
+	d.compression = compression
+	d.setCentroids(centroids)
+	d.sum = sum
+	return nil
+}
+
+// orderedBits returns the bits of x as an integer which sorts in the same
+// order as x. Negative numbers have all their bits flipped so they sort in
+// reverse and below positive numbers, which have their sign bit set.
+func orderedBits(x float64) uint64 {
+	bits := math.Float64bits(x)
+	if bits>>63 == 1 {
+		return ^bits
+	}
+	return bits | 1<<63
+}
+
+// fromOrderedBits is the inverse of orderedBits.
+func fromOrderedBits(key uint64) float64 {
+	if key>>63 == 1 {
+		return math.Float64frombits(key &^ (1 << 63))
+	}
+	return math.Float64frombits(^key)
+}
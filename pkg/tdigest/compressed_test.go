@@ -0,0 +1,159 @@
+package tdigest_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_MarshalCompressed(t *testing.T) {
+	weighted := tdigest.New(tdigest.WithCompression(20))
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		weighted.AddWeighted(r.NormFloat64(), r.Float64()+0.1)
+	}
+	signed := tdigest.New(tdigest.WithCompression(1))
+	for _, v := range []float64{-1e300, -2, math.Copysign(0, -1), 0, 1e-300, 3, 1e300} {
+		signed.Add(v)
+	}
+
+	tcs := []struct {
+		name   string
+		digest *tdigest.TDigest
+	}{{
+		name:   "empty",
+		digest: tdigest.New(),
+	}, {
+		name:   "uniform",
+		digest: newUniformRange(20, 10000, 0, 1, 1),
+	}, {
+		name:   "fractional counts",
+		digest: weighted,
+	}, {
+		name:   "signed",
+		digest: signed,
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := tc.digest.MarshalCompressed()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := tdigest.New(tdigest.WithCompression(100))
+			if err = got.UnmarshalCompressed(b); err != nil {
+				t.Fatal(err)
+			}
+
+			// The encoding is lossless, so it decodes to the same TDigest as
+			// MarshalBinary's.
+			want := tdigest.Must(tc.digest.Serialize())
+			if got, want := got.Serialize(), want.Serialize(); string(got) != string(want) {
+				t.Errorf("got MarshalBinary() %v after decoding, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestTDigest_MarshalCompressed_Size(t *testing.T) {
+	digest := newUniformRange(100, 100000, 0, 1, 1)
+	b, err := digest.MarshalCompressed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, max := len(b), len(digest.Serialize())*3/4; got > max {
+		t.Errorf("got %d bytes, want at most %d", got, max)
+	}
+}
+
+func TestTDigest_UnmarshalCompressed_Invalid(t *testing.T) {
+	b, err := newUniformRange(20, 10000, 0, 1, 1).MarshalCompressed()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	badMagic := append([]byte{}, b...)
+	badMagic[0] = 'X'
+	badVersion := append([]byte{}, b...)
+	badVersion[3] = 99
+	trailing := append(append([]byte{}, b...), 0)
+	// One centroid, whose count has the float64-follows bit but no float64.
+	missingCount := append([]byte{}, b[:28]...)
+	missingCount = append(missingCount, 1, 0, 1)
+
+	tcs := []struct {
+		name string
+		b    []byte
+	}{
+		{name: "empty", b: nil},
+		{name: "binary", b: newUniformRange(20, 10000, 0, 1, 1).Serialize()},
+		{name: "bad magic", b: badMagic},
+		{name: "bad version", b: badVersion},
+		{name: "trailing byte", b: trailing},
+		{name: "missing count", b: missingCount},
+	}
+	for i := 1; i < len(b); i += len(b) / 20 {
+		tcs = append(tcs, struct {
+			name string
+			b    []byte
+		}{name: fmt.Sprintf("truncated to %d", i), b: b[:i]})
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			// An invalid encoding leaves the TDigest as it was.
+			digest := newUniformRange(20, 1000, 5, 6, 2)
+			want := digest.Clone()
+			if err := digest.UnmarshalCompressed(tc.b); err == nil {
+				t.Error("got no error")
+			}
+			if !digest.Equal(want) {
+				t.Error("got TDigest changed by invalid encoding")
+			}
+		})
+	}
+}
+
+// BenchmarkTDigest_MarshalCompressed compares the size and speed of
+// MarshalCompressed and MarshalBinary for digests of different sizes and
+// compressions. ratio is the compressed size over the uncompressed size.
+func BenchmarkTDigest_MarshalCompressed(b *testing.B) {
+	for _, compression := range []float64{5, 20, 100} {
+		for _, n := range []int{1000, 100000} {
+			digest := newUniformRange(compression, n, 0, 1, 1)
+			uncompressed := digest.Serialize()
+			compressed, _ := digest.MarshalCompressed()
+			ratio := float64(len(compressed)) / float64(len(uncompressed))
+			name := fmt.Sprintf("compression=%v/n=%d", compression, n)
+
+			b.Run(name+"/Binary", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_, _ = digest.MarshalBinary()
+				}
+				b.ReportMetric(float64(len(uncompressed)), "bytes")
+			})
+			b.Run(name+"/Compressed", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_, _ = digest.MarshalCompressed()
+				}
+				b.ReportMetric(float64(len(compressed)), "bytes")
+				b.ReportMetric(ratio, "ratio")
+			})
+			b.Run(name+"/UnmarshalBinary", func(b *testing.B) {
+				d := tdigest.New()
+				for i := 0; i < b.N; i++ {
+					_ = d.UnmarshalBinary(uncompressed)
+				}
+			})
+			b.Run(name+"/UnmarshalCompressed", func(b *testing.B) {
+				d := tdigest.New()
+				for i := 0; i < b.N; i++ {
+					_ = d.UnmarshalCompressed(compressed)
+				}
+			})
+		}
+	}
+}
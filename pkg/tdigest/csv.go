@@ -0,0 +1,97 @@
+package tdigest
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// csvHeader is the header row written by WriteCSV and expected by ReadCSV.
+var csvHeader = []string{"mean", "count"}
+
+// WriteCSV writes the TDigest's centroids to w as CSV, for analysis in other
+// tools: a header row "mean,count" followed by one row per centroid in order
+// of increasing mean. Values are written with full precision.
+func (d *TDigest) WriteCSV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	cw := csv.NewWriter(bw)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	row := make([]string, 2)
+	for i := range d.centroids {
+		c := &d.centroids[i]
+		row[0] = strconv.FormatFloat(c.mean, 'g', -1, 64)
+		row[1] = strconv.FormatFloat(c.count, 'g', -1, 64)
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ReadCSV returns a TDigest with DefaultCompression and the centroids read from
+// r in the format of WriteCSV. Rows may be in any order; they are sorted by
+// mean. It returns an error if the header is missing or a row has a count
+// which isn't positive.
+//
+// The compression isn't part of the format, so set it with the centroids
+// using FromCentroids if it matters.
+func ReadCSV(r io.Reader) (*TDigest, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvHeader)
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("tdigest: CSV has no header")
+		}
+		return nil, fmt.Errorf("tdigest: reading CSV: %w", err)
+	}
+	if header[0] != csvHeader[0] || header[1] != csvHeader[1] {
+		return nil, fmt.Errorf("tdigest: got CSV header %q, want %q", header, csvHeader)
+	}
+
+	var centroids []centroid
+	var count float64
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("tdigest: reading CSV: %w", err)
+		}
+
+		line, _ := cr.FieldPos(0)
+		mean, err := strconv.ParseFloat(row[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("tdigest: CSV line %d: invalid mean: %w", line, err)
+		}
+		c, err := strconv.ParseFloat(row[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("tdigest: CSV line %d: invalid count: %w", line, err)
+		}
+		centroids = append(centroids, centroid{mean: mean, count: c})
+		count += c
+	}
+
+	sort.SliceStable(centroids, func(i, j int) bool {
+		return centroids[i].mean < centroids[j].mean
+	})
+	if err := validateCentroids(centroids, count); err != nil {
+		return nil, err
+	}
+
+	d := New()
+	d.setCentroids(centroids)
+	return d, nil
+}
@@ -0,0 +1,98 @@
+package tdigest_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_WriteCSV(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(1))
+	for _, val := range []float64{0.1, 2, 2, 3e10} {
+		digest.Add(val)
+	}
+
+	var b bytes.Buffer
+	if err := digest.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "mean,count\n0.1,1\n2,2\n3e+10,1\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	digest := newUniformRange(20, 100000, 0, 1, 1)
+
+	var b bytes.Buffer
+	if err := digest.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	got, err := tdigest.ReadCSV(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Count() != digest.Count() {
+		t.Errorf("got Count() = %v, want %v", got.Count(), digest.Count())
+	}
+	// The exact minimum and maximum aren't in the CSV, so the most extreme
+	// quantiles may differ.
+	for i := 1; i < 100; i++ {
+		q := float64(i) / 100
+		got, want := mustFloat(got.Quantile(q)), mustFloat(digest.Quantile(q))
+		if math.Abs(got-want) > 1e-4*math.Abs(want) {
+			t.Errorf("got Quantile(%v) = %v, want %v", q, got, want)
+		}
+	}
+}
+
+func TestReadCSV_Unsorted(t *testing.T) {
+	got, err := tdigest.ReadCSV(strings.NewReader("mean,count\n3,1\n1,2\n2,1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []tdigest.CentroidData{{Mean: 1, Count: 2}, {Mean: 2, Count: 1}, {Mean: 3, Count: 1}}
+	for i, c := range got.Centroids() {
+		if c != want[i] {
+			t.Errorf("got centroid %d = %v, want %v", i, c, want[i])
+		}
+	}
+}
+
+func TestReadCSV_Invalid(t *testing.T) {
+	tcs := []struct {
+		name string
+		csv  string
+	}{{
+		name: "empty",
+		csv:  "",
+	}, {
+		name: "wrong header",
+		csv:  "count,mean\n1,1\n",
+	}, {
+		name: "missing field",
+		csv:  "mean,count\n1\n",
+	}, {
+		name: "invalid mean",
+		csv:  "mean,count\nx,1\n",
+	}, {
+		name: "zero count",
+		csv:  "mean,count\n1,0\n",
+	}, {
+		name: "infinite mean",
+		csv:  "mean,count\n+Inf,1\n",
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tdigest.ReadCSV(strings.NewReader(tc.csv)); err == nil {
+				t.Errorf("got no error reading %q", tc.csv)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package tdigest
+
+import "fmt"
+
+// decayRenormalizeScale is the scale below which a DecayTDigest applies its
+// decay to the centroids. Until then, stored counts overstate decayed counts
+// by less than a factor of 1/decayRenormalizeScale.
+const decayRenormalizeScale = 0.5
+
+// DecayTDigest weights observations by their age, so older observations lose
+// influence. Each Add multiplies the weight of every earlier observation by
+// alpha.
+//
+// Scaling every centroid on each Add would be O(n) in the number of
+// centroids, so the decay is tracked as a single scale factor instead. New
+// observations are added with weight 1/scale, and the scale is applied to the
+// centroids only once it falls below decayRenormalizeScale. Until then,
+// centroids fill slightly faster than their decayed counts suggest.
+type DecayTDigest struct {
+	digest *TDigest
+	alpha  float64
+
+	// scale converts the counts stored in digest into decayed counts.
+	scale float64
+}
+
+// NewDecay panics if alpha is not in (0, 1).
+func NewDecay(compression, alpha float64) *DecayTDigest {
+	if !(alpha > 0 && alpha < 1) {
+		panic(fmt.Sprintf("tdigest: decay factor must be in (0, 1), got %v", alpha))
+	}
+	return &DecayTDigest{
+		digest: New(WithCompression(compression)),
+		alpha:  alpha,
+		scale:  1,
+	}
+}
+
+// Add decays the weight of every earlier observation by alpha, then adds val
+// with a weight of 1. It returns ErrInvalidValue if val is NaN or infinite.
+func (d *DecayTDigest) Add(val float64) error {
+	if !isValid(val) {
+		return ErrInvalidValue
+	}
+
+	d.scale *= d.alpha
+	if d.scale < decayRenormalizeScale {
+		d.renormalize()
+	}
+
+	weight := 1 / d.scale
+	d.digest.addWeighted(val, weight, false)
+	d.digest.count += weight
+	d.digest.sum += val * weight
+	return nil
+}
+
+// renormalize applies the scale to the stored counts and resets it to 1.
+// Centroids whose counts underflow to zero are dropped.
+func (d *DecayTDigest) renormalize() {
+	kept := d.digest.centroids[:0]
+	for _, c := range d.digest.centroids {
+		c.count *= d.scale
+		if c.count > 0 {
+			kept = append(kept, c)
+		}
+	}
+
+	if len(kept) < d.digest.nCentroids {
+		d.digest.setCentroids(kept)
+	} else {
+		d.digest.count *= d.scale
+		d.digest.sum *= d.scale
+		d.digest.prefixValid = false
+	}
+	d.scale = 1
+}
+
+// Count returns the sum of the decayed weights of all observations.
+func (d *DecayTDigest) Count() float64 {
+	return d.digest.count * d.scale
+}
+
+// Quantile returns the quantile q of the observations weighted by their
+// decayed weights, or ErrEmptyDigest if none have been added.
+func (d *DecayTDigest) Quantile(q float64) (float64, error) {
+	// Quantiles depend only on relative weights, so the scale doesn't matter.
+	return d.digest.Quantile(q)
+}
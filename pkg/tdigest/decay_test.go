@@ -0,0 +1,92 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestDecayTDigest(t *testing.T) {
+	tcs := []struct {
+		name  string
+		alpha float64
+		// wantNew is whether the median should come from the new values.
+		wantNew bool
+	}{{
+		name:    "fast decay",
+		alpha:   0.95,
+		wantNew: true,
+	}, {
+		name:    "slow decay",
+		alpha:   0.99999,
+		wantNew: false,
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			digest := tdigest.NewDecay(20, tc.alpha)
+
+			for i := 0; i < 1000; i++ {
+				digest.Add(r.Float64())
+			}
+			for i := 0; i < 100; i++ {
+				digest.Add(5)
+			}
+
+			// The weights are a geometric series.
+			wantCount := (1 - math.Pow(tc.alpha, 1100)) / (1 - tc.alpha)
+			if got := digest.Count(); math.Abs(got-wantCount) > 1e-6*wantCount {
+				t.Errorf("got Count() = %v, want %v", got, wantCount)
+			}
+
+			got := mustFloat(digest.Quantile(0.5))
+			if tc.wantNew && math.Abs(got-5) > 0.1 {
+				t.Errorf("got Quantile(0.5) = %v, want approximately 5 from the new values", got)
+			}
+			if !tc.wantNew && got > 1 {
+				t.Errorf("got Quantile(0.5) = %v, want at most 1 from the old values", got)
+			}
+		})
+	}
+}
+
+func TestDecayTDigest_Long(t *testing.T) {
+	// Enough adds for the weights of the first values to underflow.
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.NewDecay(20, 0.5)
+	for i := 0; i < 5000; i++ {
+		digest.Add(r.Float64())
+	}
+
+	if got, want := digest.Count(), 2.0; math.Abs(got-want) > 1e-9 {
+		t.Errorf("got Count() = %v, want %v", got, want)
+	}
+	got := mustFloat(digest.Quantile(0.5))
+	if math.IsNaN(got) || got < 0 || got > 1 {
+		t.Errorf("got Quantile(0.5) = %v, want in [0, 1]", got)
+	}
+}
+
+func TestDecayTDigest_Invalid(t *testing.T) {
+	digest := tdigest.NewDecay(20, 0.9)
+	if err := digest.Add(math.NaN()); err != tdigest.ErrInvalidValue {
+		t.Errorf("got error %v adding NaN, want %v", err, tdigest.ErrInvalidValue)
+	}
+	if _, err := digest.Quantile(0.5); err != tdigest.ErrEmptyDigest {
+		t.Errorf("got error %v for empty digest, want %v", err, tdigest.ErrEmptyDigest)
+	}
+
+	for _, alpha := range []float64{0, 1, -0.5, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("got no panic for alpha %v", alpha)
+				}
+			}()
+			tdigest.NewDecay(20, alpha)
+		}()
+	}
+}
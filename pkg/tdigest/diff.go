@@ -0,0 +1,87 @@
+package tdigest
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// minRelDeltaScale is the smallest denominator used when computing the
+// relative difference between two quantile values, so values near zero don't
+// produce huge relative differences.
+const minRelDeltaScale = 1e-9
+
+// DiffPoint is the difference between two TDigests at a single quantile.
+type DiffPoint struct {
+	Q          float64
+	SelfValue  float64
+	OtherValue float64
+
+	// Delta is OtherValue - SelfValue, so it is positive if the quantile
+	// increased from the TDigest to other.
+	Delta float64
+	// AbsDelta is |SelfValue - OtherValue|.
+	AbsDelta float64
+	// RelDelta is AbsDelta relative to the larger magnitude of SelfValue and
+	// OtherValue.
+	RelDelta float64
+}
+
+// Diff compares the TDigest with other at each of quantiles. Results are
+// returned in the same order as quantiles.
+func (d *TDigest) Diff(other *TDigest, quantiles []float64) []DiffPoint {
+	result := make([]DiffPoint, len(quantiles))
+	for i, q := range quantiles {
+		self := d.quantile(q)
+		otherValue := other.quantile(q)
+		delta := otherValue - self
+		absDelta := math.Abs(delta)
+		scale := math.Max(math.Max(math.Abs(self), math.Abs(otherValue)), minRelDeltaScale)
+		result[i] = DiffPoint{
+			Q:          q,
+			SelfValue:  self,
+			OtherValue: otherValue,
+			Delta:      delta,
+			AbsDelta:   absDelta,
+			RelDelta:   absDelta / scale,
+		}
+	}
+	return result
+}
+
+// MaxDiff returns the DiffPoint with the largest AbsDelta among quantiles.
+// It returns the zero DiffPoint if quantiles is empty.
+func (d *TDigest) MaxDiff(other *TDigest, quantiles []float64) DiffPoint {
+	var result DiffPoint
+	for i, point := range d.Diff(other, quantiles) {
+		if i == 0 || point.AbsDelta > result.AbsDelta {
+			result = point
+		}
+	}
+	return result
+}
+
+// summaryQuantiles are the quantiles of HistogramSummary, which DiffSummary
+// compares.
+var summaryQuantiles = []float64{0.5, 0.75, 0.9, 0.95, 0.99, 0.999}
+
+// DiffSummary formats Diff at p50, p75, p90, p95, p99 and p99.9 as a table
+// with a row for each quantile, e.g.
+//
+//	quantile  self  other  delta
+//	p50       1.2   1.25   +0.05
+//	...
+//	p99.9     6.1   5.8    -0.3
+func (d *TDigest) DiffSummary(other *TDigest) string {
+	sb := strings.Builder{}
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "quantile\tself\tother\tdelta")
+	for _, point := range d.Diff(other, summaryQuantiles) {
+		fmt.Fprintf(w, "p%s\t%g\t%g\t%+g\n",
+			strconv.FormatFloat(100*point.Q, 'g', -1, 64), point.SelfValue, point.OtherValue, point.Delta)
+	}
+	w.Flush()
+	return sb.String()
+}
@@ -0,0 +1,96 @@
+package tdigest_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+var diffQuantiles = []float64{0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99}
+
+func TestTDigest_Diff(t *testing.T) {
+	a := newUniformRange(100, 10000, 0, 1, 1)
+	same := newUniformRange(100, 10000, 0, 1, 1)
+
+	diff := a.Diff(same, diffQuantiles)
+	if len(diff) != len(diffQuantiles) {
+		t.Fatalf("got %d points, want %d", len(diff), len(diffQuantiles))
+	}
+	for i, point := range diff {
+		if point.Q != diffQuantiles[i] {
+			t.Errorf("got Q %v, want %v", point.Q, diffQuantiles[i])
+		}
+		if point.SelfValue != point.OtherValue || point.AbsDelta != 0 || point.RelDelta != 0 {
+			t.Errorf("got %+v for identical digests, want no difference", point)
+		}
+	}
+}
+
+func TestTDigest_Diff_Shifted(t *testing.T) {
+	a := newUniformRange(100, 10000, 0, 1, 1)
+	b := newUniformRange(100, 10000, 1, 2, 1)
+
+	for _, point := range a.Diff(b, diffQuantiles) {
+		if math.Abs(point.AbsDelta-1) > 1e-9 {
+			t.Errorf("got AbsDelta %v at %v, want 1", point.AbsDelta, point.Q)
+		}
+		if want := point.AbsDelta / point.OtherValue; math.Abs(point.RelDelta-want) > 1e-9 {
+			t.Errorf("got RelDelta %v at %v, want %v", point.RelDelta, point.Q, want)
+		}
+	}
+
+	// Delta is positive from a to b, and negative from b to a.
+	for _, point := range a.Diff(b, diffQuantiles) {
+		if math.Abs(point.Delta-1) > 1e-9 {
+			t.Errorf("got Delta %v at %v from a to b, want 1", point.Delta, point.Q)
+		}
+	}
+	for _, point := range b.Diff(a, diffQuantiles) {
+		if math.Abs(point.Delta+1) > 1e-9 {
+			t.Errorf("got Delta %v at %v from b to a, want -1", point.Delta, point.Q)
+		}
+	}
+}
+
+func TestTDigest_MaxDiff(t *testing.T) {
+	a := newUniformRange(100, 10000, 0, 1, 1)
+	b := newUniformRange(100, 10000, 0, 2, 2)
+
+	got := a.MaxDiff(b, diffQuantiles)
+	for _, point := range a.Diff(b, diffQuantiles) {
+		if point.AbsDelta > got.AbsDelta {
+			t.Errorf("got MaxDiff %+v, but %+v has a larger AbsDelta", got, point)
+		}
+	}
+	// The largest difference between uniform [0, 1] and uniform [0, 2] is at
+	// the largest quantile.
+	if got.Q != 0.99 || got.AbsDelta < 0.9 {
+		t.Errorf("got MaxDiff %+v, want AbsDelta of approximately 0.99 at 0.99", got)
+	}
+
+	if got := a.MaxDiff(b, nil); got.AbsDelta != 0 {
+		t.Errorf("got MaxDiff %+v for no quantiles, want zero", got)
+	}
+}
+
+func TestTDigest_DiffSummary(t *testing.T) {
+	a := newUniformRange(100, 10000, 0, 1, 1)
+	b := newUniformRange(100, 10000, 0, 2, 2)
+
+	lines := strings.Split(strings.TrimSuffix(a.DiffSummary(b), "\n"), "\n")
+	wantLabels := []string{"quantile", "p50", "p75", "p90", "p95", "p99", "p99.9"}
+	if len(lines) != len(wantLabels) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(wantLabels), strings.Join(lines, "\n"))
+	}
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 4 || fields[0] != wantLabels[i] {
+			t.Errorf("got line %q, want 4 columns starting with %q", line, wantLabels[i])
+			continue
+		}
+		// Every quantile of uniform [0, 2] is larger than that of [0, 1].
+		if i > 0 && !strings.HasPrefix(fields[3], "+") {
+			t.Errorf("got delta %v for %v, want positive", fields[3], fields[0])
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package tdigest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// digestDiffVersion is the version of the DigestDiff binary encoding.
+const digestDiffVersion = 1
+
+// DigestDiff is the change between two snapshots of a TDigest: the centroids
+// which were removed from the first snapshot and those which were added to
+// make the second.
+//
+// Sending a DigestDiff instead of the full TDigest reduces bandwidth when
+// synchronizing digests which change a little at a time.
+type DigestDiff struct {
+	compression float64
+
+	// removed are the increasing indices of centroids removed from before.
+	removed []int
+	// added are the centroids added to make after, in order of increasing
+	// mean.
+	added []centroid
+}
+
+// NewDigestDiff returns the DigestDiff which transforms before into after.
+func NewDigestDiff(before, after *TDigest) *DigestDiff {
+	diff := &DigestDiff{compression: after.Compression()}
+
+	// Both lists of centroids are sorted by mean, so walk them together.
+	i, j := 0, 0
+	for i < len(before.centroids) && j < len(after.centroids) {
+		b, a := before.centroids[i], after.centroids[j]
+		switch {
+		case b.mean == a.mean && b.count == a.count:
+			i++
+			j++
+		case b.mean < a.mean:
+			diff.removed = append(diff.removed, i)
+			i++
+		case a.mean < b.mean:
+			diff.added = append(diff.added, centroid{mean: a.mean, count: a.count})
+			j++
+		default:
+			// Same mean, but the count changed.
+			diff.removed = append(diff.removed, i)
+			diff.added = append(diff.added, centroid{mean: a.mean, count: a.count})
+			i++
+			j++
+		}
+	}
+	for ; i < len(before.centroids); i++ {
+		diff.removed = append(diff.removed, i)
+	}
+	for ; j < len(after.centroids); j++ {
+		a := after.centroids[j]
+		diff.added = append(diff.added, centroid{mean: a.mean, count: a.count})
+	}
+
+	return diff
+}
+
+// Apply returns a new TDigest made by removing and adding the diff's centroids
+// to base. Applying the diff to the TDigest it was computed from reproduces
+// the later snapshot. It returns an error if the diff removes centroids which
+// base doesn't have, or adds invalid centroids.
+func (diff *DigestDiff) Apply(base *TDigest) (*TDigest, error) {
+	for i, idx := range diff.removed {
+		if idx < 0 || idx >= len(base.centroids) || (i > 0 && idx <= diff.removed[i-1]) {
+			return nil, fmt.Errorf("tdigest: DigestDiff removes centroid %d of %d", idx, len(base.centroids))
+		}
+	}
+
+	// Every removed centroid is in base, so the capacity isn't negative.
+	centroids := make([]centroid, 0, len(base.centroids)-len(diff.removed)+len(diff.added))
+
+	r := 0
+	for i, c := range base.centroids {
+		if r < len(diff.removed) && diff.removed[r] == i {
+			r++
+			continue
+		}
+		centroids = append(centroids, centroid{mean: c.mean, count: c.count})
+	}
+	for _, c := range diff.added {
+		centroids = append(centroids, centroid{mean: c.mean, count: c.count})
+	}
+	sort.SliceStable(centroids, func(i, j int) bool {
+		return centroids[i].mean < centroids[j].mean
+	})
+	var count float64
+	for _, c := range centroids {
+		count += c.count
+	}
+	if err := validateCentroids(centroids, count); err != nil {
+		return nil, err
+	}
+
+	result := New(WithCompression(diff.compression))
+	result.setCentroids(centroids)
+	return result, nil
+}
+
+// Size returns the size of the binary encoding of the diff in bytes.
+func (diff *DigestDiff) Size() int {
+	n := 1 + 8 + uvarintSize(uint64(len(diff.removed)))
+	prev := 0
+	for _, idx := range diff.removed {
+		n += uvarintSize(uint64(idx - prev))
+		prev = idx
+	}
+	return n + uvarintSize(uint64(len(diff.added))) + 16*len(diff.added)
+}
+
+// MarshalBinary encodes the diff as a version byte, the compression, the
+// delta-encoded indices of removed centroids, and the added centroids.
+func (diff *DigestDiff) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, diff.Size())
+	b = append(b, digestDiffVersion)
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(diff.compression))
+
+	b = binary.AppendUvarint(b, uint64(len(diff.removed)))
+	prev := 0
+	for _, idx := range diff.removed {
+		b = binary.AppendUvarint(b, uint64(idx-prev))
+		prev = idx
+	}
+
+	b = binary.AppendUvarint(b, uint64(len(diff.added)))
+	for _, c := range diff.added {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(c.mean))
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(c.count))
+	}
+	return b, nil
+}
+
+var errTruncatedDiff = errors.New("tdigest: truncated DigestDiff")
+
+// UnmarshalBinary decodes a diff encoded by MarshalBinary.
+func (diff *DigestDiff) UnmarshalBinary(b []byte) error {
+	if len(b) < 9 {
+		return errTruncatedDiff
+	}
+	if b[0] != digestDiffVersion {
+		return fmt.Errorf("tdigest: unknown DigestDiff version %d", b[0])
+	}
+	compression := math.Float64frombits(binary.LittleEndian.Uint64(b[1:]))
+	b = b[9:]
+
+	nRemoved, n := binary.Uvarint(b)
+	if n <= 0 || nRemoved > uint64(len(b)) {
+		return errTruncatedDiff
+	}
+	b = b[n:]
+	removed := make([]int, nRemoved)
+	prev := 0
+	for i := range removed {
+		delta, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errTruncatedDiff
+		}
+		// Indices are increasing, and must fit in an int.
+		if (i > 0 && delta == 0) || delta > uint64(math.MaxInt-prev) {
+			return fmt.Errorf("tdigest: DigestDiff removed index %d is out of range", i)
+		}
+		b = b[n:]
+		prev += int(delta)
+		removed[i] = prev
+	}
+
+	nAdded, n := binary.Uvarint(b)
+	if n <= 0 {
+		return errTruncatedDiff
+	}
+	b = b[n:]
+	// Check nAdded before multiplying, which could overflow.
+	if nAdded > uint64(len(b))/16 || uint64(len(b)) != 16*nAdded {
+		return fmt.Errorf("tdigest: got %d bytes for %d added centroids", len(b), nAdded)
+	}
+	added := make([]centroid, nAdded)
+	for i := range added {
+		added[i].mean = math.Float64frombits(binary.LittleEndian.Uint64(b[16*i:]))
+		added[i].count = math.Float64frombits(binary.LittleEndian.Uint64(b[16*i+8:]))
+	}
+
+	diff.compression = compression
+	diff.removed = removed
+	diff.added = added
+	return nil
+}
+
+// uvarintSize returns the number of bytes needed to varint-encode x.
+func uvarintSize(x uint64) int {
+	n := 1
+	for ; x >= 0x80; x >>= 7 {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,120 @@
+package tdigest_test
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestDigestDiff_Apply(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		n := 1000 + r.Intn(10000)
+
+		before := newUniformRange(20, n, 0, 1, seed)
+		after := newUniformRange(20, n, 0, 1, seed)
+		// Randomly mutate after.
+		for i := r.Intn(1000); i >= 0; i-- {
+			after.Add(r.NormFloat64())
+		}
+
+		diff := tdigest.NewDigestDiff(before, after)
+		applied, err := diff.Apply(before)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := applied.String(), after.String(); got != want {
+			t.Errorf("seed %d: got Apply(before) = %v, want %v", seed, got, want)
+		}
+
+		b, err := diff.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b) != diff.Size() {
+			t.Errorf("seed %d: got %d bytes, want Size() = %d", seed, len(b), diff.Size())
+		}
+
+		decoded := &tdigest.DigestDiff{}
+		if err = decoded.UnmarshalBinary(b); err != nil {
+			t.Fatal(err)
+		}
+		applied, err = decoded.Apply(before)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := applied.String(), after.String(); got != want {
+			t.Errorf("seed %d: got decoded Apply(before) = %v, want %v", seed, got, want)
+		}
+	}
+}
+
+func TestDigestDiff_Unchanged(t *testing.T) {
+	before := newUniformRange(20, 1000, 0, 1, 1)
+	after := newUniformRange(20, 1000, 0, 1, 1)
+
+	diff := tdigest.NewDigestDiff(before, after)
+	// Just the version, compression, and two zero lengths.
+	if got := diff.Size(); got != 11 {
+		t.Errorf("got Size() = %d for unchanged digest, want %d", got, 11)
+	}
+}
+
+func TestDigestDiff_UnmarshalBinary_Malformed(t *testing.T) {
+	before := newUniformRange(20, 1000, 0, 1, 1)
+	after := newUniformRange(20, 2000, 0, 1, 2)
+	b, err := tdigest.NewDigestDiff(before, after).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < len(b); i++ {
+		if err = (&tdigest.DigestDiff{}).UnmarshalBinary(b[:i]); err == nil {
+			t.Errorf("got no error unmarshalling %d of %d bytes", i, len(b))
+		}
+	}
+
+	b[0] = 2
+	if err = (&tdigest.DigestDiff{}).UnmarshalBinary(b); err == nil {
+		t.Error("got no error unmarshalling unknown version")
+	}
+}
+
+func TestDigestDiff_UnmarshalBinary_Overflow(t *testing.T) {
+	// No removed centroids, then 1<<60 added centroids, which overflows
+	// 16*nAdded.
+	b := append([]byte{1}, make([]byte, 8)...)
+	b = binary.AppendUvarint(b, 0)
+	b = binary.AppendUvarint(b, 1<<60)
+	if err := (&tdigest.DigestDiff{}).UnmarshalBinary(b); err == nil {
+		t.Error("got no error unmarshalling 1<<60 added centroids")
+	}
+
+	// Two removed centroids whose indices overflow an int.
+	b = append([]byte{1}, make([]byte, 8)...)
+	b = binary.AppendUvarint(b, 2)
+	b = binary.AppendUvarint(b, 1)
+	b = binary.AppendUvarint(b, math.MaxUint64)
+	b = binary.AppendUvarint(b, 0)
+	if err := (&tdigest.DigestDiff{}).UnmarshalBinary(b); err == nil {
+		t.Error("got no error unmarshalling overflowing removed indices")
+	}
+}
+
+func TestDigestDiff_Apply_Mismatch(t *testing.T) {
+	small := newUniformRange(20, 100, 0, 1, 1)
+	large := newUniformRange(20, 10000, 0, 1, 2)
+
+	// Removing more centroids than small has used to make a negative
+	// capacity.
+	diff := tdigest.NewDigestDiff(large, small)
+	if _, err := diff.Apply(small); err == nil {
+		t.Error("got no error applying a diff which removes centroids the base doesn't have")
+	}
+	if _, err := diff.Apply(large); err != nil {
+		t.Errorf("got error %v applying a diff to its own base", err)
+	}
+}
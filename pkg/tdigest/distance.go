@@ -0,0 +1,148 @@
+package tdigest
+
+import (
+	"math"
+	"sort"
+)
+
+// WassersteinDistance returns the estimated Wasserstein-1 distance, or earth
+// mover's distance, between the distributions summarized by a and b, or
+// ErrEmptyDigest if either is empty.
+//
+// It integrates |a.CDF(x) - b.CDF(x)| with the trapezoidal rule over the
+// means of the centroids of both digests, so it is an approximation which
+// improves as the digests have more centroids.
+func WassersteinDistance(a, b *TDigest) (float64, error) {
+	if a.nCentroids == 0 || b.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+
+	xs := centroidMeans(a, b)
+	var distance float64
+	prev := math.Abs(a.cdf(xs[0]) - b.cdf(xs[0]))
+	for i, x := range xs[1:] {
+		diff := math.Abs(a.cdf(x) - b.cdf(x))
+		distance += (x - xs[i]) * (prev + diff) / 2
+		prev = diff
+	}
+	return distance, nil
+}
+
+// KSStatistic returns the estimated two-sample Kolmogorov-Smirnov statistic,
+// the largest absolute difference between the CDFs of a and b, or
+// ErrEmptyDigest if either is empty. The CDFs are compared at the means of the
+// centroids of both digests.
+func KSStatistic(a, b *TDigest) (float64, error) {
+	if a.nCentroids == 0 || b.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+
+	var statistic float64
+	for _, x := range centroidMeans(a, b) {
+		statistic = math.Max(statistic, math.Abs(a.cdf(x)-b.cdf(x)))
+	}
+	return statistic, nil
+}
+
+// KSTest returns the two-sample Kolmogorov-Smirnov statistic of a and b, as
+// KSStatistic, and the two-sided p-value of the hypothesis that they were drawn
+// from the same distribution, or ErrEmptyDigest if either is empty.
+//
+// The p-value is from the asymptotic Kolmogorov distribution, with the
+// effective sample size computed from the counts of a and b, so it is only
+// meaningful for unweighted observations and large counts.
+func KSTest(a, b *TDigest) (statistic, pValue float64, err error) {
+	statistic, err = KSStatistic(a, b)
+	if err != nil {
+		return math.NaN(), math.NaN(), err
+	}
+
+	n := math.Sqrt(a.count * b.count / (a.count + b.count))
+	return statistic, kolmogorovQ((n + 0.12 + 0.11/n) * statistic), nil
+}
+
+// correlationPoints is the number of evenly spaced quantiles Correlation
+// compares.
+const correlationPoints = 1000
+
+// Correlation returns the Pearson correlation of the quantiles of a and b at
+// 1000 evenly spaced points, or ErrEmptyDigest if either is empty. It is NaN if
+// either digest has no spread.
+//
+// A TDigest doesn't record which observations were made together, so this is
+// not the correlation of paired observations. It is the correlation they would
+// have if the smallest of a were always paired with the smallest of b, and so
+// on. It is 1 when a and b have the same shape, for example when one metric is
+// a linear function of the other, and smaller the more their shapes differ. It
+// is never negative, even for metrics which are inversely related.
+func Correlation(a, b *TDigest) (float64, error) {
+	if a.nCentroids == 0 || b.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+
+	xs, ys := make([]float64, correlationPoints), make([]float64, correlationPoints)
+	var meanX, meanY float64
+	for i := range xs {
+		q := (float64(i) + 0.5) / correlationPoints
+		xs[i], ys[i] = a.quantile(q), b.quantile(q)
+		meanX += xs[i] / correlationPoints
+		meanY += ys[i] / correlationPoints
+	}
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	return cov / math.Sqrt(varX*varY), nil
+}
+
+// kolmogorovQ returns the probability that the Kolmogorov distribution exceeds
+// lambda, 2 * sum over j >= 1 of (-1)^(j-1) * exp(-2 * j^2 * lambda^2).
+func kolmogorovQ(lambda float64) float64 {
+	const (
+		maxTerms = 100
+		// Stop once a term is negligible relative to the previous term or
+		// to the sum.
+		termEpsilon = 1e-3
+		sumEpsilon  = 1e-8
+	)
+
+	a := -2 * lambda * lambda
+	sign, sum, prev := 2.0, 0.0, 0.0
+	for j := 1; j <= maxTerms; j++ {
+		term := sign * math.Exp(a*float64(j*j))
+		sum += term
+		if math.Abs(term) <= termEpsilon*prev || math.Abs(term) <= sumEpsilon*sum {
+			return math.Max(0, math.Min(1, sum))
+		}
+		sign = -sign
+		prev = math.Abs(term)
+	}
+
+	// The series only fails to converge for lambda near zero.
+	return 1
+}
+
+// centroidMeans returns the distinct means of the centroids of a and b in
+// increasing order.
+func centroidMeans(a, b *TDigest) []float64 {
+	xs := make([]float64, 0, a.nCentroids+b.nCentroids)
+	for _, c := range a.centroids {
+		xs = append(xs, c.mean)
+	}
+	for _, c := range b.centroids {
+		xs = append(xs, c.mean)
+	}
+	sort.Float64s(xs)
+
+	unique := xs[:1]
+	for _, x := range xs[1:] {
+		if x != unique[len(unique)-1] {
+			unique = append(unique, x)
+		}
+	}
+	return unique
+}
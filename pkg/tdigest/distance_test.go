@@ -0,0 +1,106 @@
+package tdigest_test
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestWassersteinDistance(t *testing.T) {
+	tcs := []struct {
+		name   string
+		b      *tdigest.TDigest
+		want   float64
+		within float64
+	}{{
+		name:   "same",
+		b:      newUniformRange(100, 100000, 0, 1, 1),
+		want:   0,
+		within: 1e-9,
+	}, {
+		name:   "overlapping",
+		b:      newUniformRange(100, 100000, 0.5, 1.5, 2),
+		want:   0.5,
+		within: 0.01,
+	}, {
+		name:   "disjoint",
+		b:      newUniformRange(100, 100000, 2, 3, 2),
+		want:   2,
+		within: 0.01,
+	}}
+
+	a := newUniformRange(100, 100000, 0, 1, 1)
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := mustFloat(tdigest.WassersteinDistance(a, tc.b))
+			if math.Abs(got-tc.want) > tc.within {
+				t.Errorf("got WassersteinDistance() = %v, want %v +/- %v", got, tc.want, tc.within)
+			}
+			if reversed := mustFloat(tdigest.WassersteinDistance(tc.b, a)); reversed != got {
+				t.Errorf("got WassersteinDistance() = %v with arguments reversed, want %v", reversed, got)
+			}
+		})
+	}
+
+	if got, err := tdigest.WassersteinDistance(a, tdigest.New()); !errors.Is(err, tdigest.ErrEmptyDigest) || !math.IsNaN(got) {
+		t.Errorf("got WassersteinDistance() = %v, %v with empty TDigest, want NaN, %v", got, err, tdigest.ErrEmptyDigest)
+	}
+}
+
+func TestKSTest(t *testing.T) {
+	a := newUniformRange(100, 10000, 0, 1, 1)
+
+	// Digests of the same distribution aren't significantly different.
+	same := newUniformRange(100, 10000, 0, 1, 2)
+	statistic, pValue, err := tdigest.KSTest(a, same)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mustFloat(tdigest.KSStatistic(a, same)); got != statistic {
+		t.Errorf("got KSStatistic() = %v, want %v from KSTest()", got, statistic)
+	}
+	if statistic > 0.05 || pValue < 0.05 {
+		t.Errorf("got statistic %v with p-value %v for the same distribution, want p-value above 0.05", statistic, pValue)
+	}
+
+	// Shifted distributions are.
+	shifted := newUniformRange(100, 10000, 0.1, 1.1, 2)
+	statistic, pValue, err = tdigest.KSTest(a, shifted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statistic < 0.1 || pValue > 1e-6 {
+		t.Errorf("got statistic %v with p-value %v for shifted distributions, want at least 0.1 with p-value below 1e-6", statistic, pValue)
+	}
+
+	if _, _, err := tdigest.KSTest(tdigest.New(), a); !errors.Is(err, tdigest.ErrEmptyDigest) {
+		t.Errorf("got error %v with empty TDigest, want %v", err, tdigest.ErrEmptyDigest)
+	}
+}
+
+func TestCorrelation(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	x := tdigest.New(tdigest.WithCompression(20))
+	linear := tdigest.New(tdigest.WithCompression(20))
+	exponential := tdigest.New(tdigest.WithCompression(20))
+	for i := 0; i < 100000; i++ {
+		val := r.NormFloat64()
+		x.Add(val)
+		linear.Add(3*val + 2)
+		exponential.Add(r.ExpFloat64())
+	}
+
+	if got := mustFloat(tdigest.Correlation(x, linear)); math.Abs(got-1) > 1e-3 {
+		t.Errorf("got Correlation() = %v for linearly related values, want 1", got)
+	}
+	if got := mustFloat(tdigest.Correlation(x, exponential)); got > 0.95 {
+		t.Errorf("got Correlation() = %v for normal and exponential values, want at most 0.95", got)
+	}
+
+	if got, err := tdigest.Correlation(x, tdigest.New()); !errors.Is(err, tdigest.ErrEmptyDigest) || !math.IsNaN(got) {
+		t.Errorf("got Correlation() = %v, %v with empty TDigest, want NaN, %v", got, err, tdigest.ErrEmptyDigest)
+	}
+}
@@ -0,0 +1,27 @@
+// Package tdigest implements the t-digest, a compact sketch of a distribution
+// which estimates quantiles, especially extreme ones, from a stream of values.
+//
+// # Empty digests
+//
+// Queries on a TDigest with no observations return NaN and ErrEmptyDigest.
+// This covers Quantile, Quantiles, Percentile, Percentiles, CDF, PDF, KDE,
+// OptimalBandwidth, Min, Max, Mean, Variance, StdDev, Skewness, Kurtosis,
+// Median, IQR, TrimmedMean, ConditionalMean, ExpectedShortfall, Entropy, Gini,
+// OutlierScore, Sample, Samples and AppendTo, the Quantile methods of the types
+// which wrap a TDigest, and WassersteinDistance, KSStatistic, KSTest and
+// Correlation if either TDigest is empty.
+// IsTukeysOutlier has no NaN bool, so returns false with ErrEmptyDigest.
+// DurationDigest has no NaN Duration, so returns 0 with ErrEmptyDigest.
+//
+// Earlier versions returned a bare NaN, which couldn't be told apart from a
+// NaN result without also checking Count. To migrate, check the error:
+//
+//	p99, err := d.Quantile(0.99)
+//	if errors.Is(err, tdigest.ErrEmptyDigest) {
+//		// No observations yet.
+//	}
+//
+// Callers which already know the TDigest has observations may use
+// MustQuantile, which panics instead of returning ErrEmptyDigest.
+// QuantileRank still returns 0 for an empty TDigest.
+package tdigest
@@ -0,0 +1,62 @@
+package tdigest
+
+import (
+	"math"
+	"time"
+)
+
+// DurationDigest is a TDigest of time.Durations, such as request latencies.
+// Durations are stored as float64 nanoseconds, and estimates are rounded to
+// the nearest nanosecond.
+type DurationDigest struct {
+	digest *TDigest
+}
+
+// NewDuration returns an empty DurationDigest with the given compression.
+func NewDuration(compression float64) *DurationDigest {
+	return &DurationDigest{digest: New(WithCompression(compression))}
+}
+
+// Add adds val to the DurationDigest. Every Duration is a valid observation, so
+// unlike TDigest.Add there is no error.
+func (d *DurationDigest) Add(val time.Duration) {
+	_ = d.digest.Add(float64(val))
+}
+
+// Quantile returns the estimated duration at quantile q, or ErrEmptyDigest if
+// the DurationDigest is empty. q is clamped to [0, 1].
+func (d *DurationDigest) Quantile(q float64) (time.Duration, error) {
+	return toDuration(d.digest.Quantile(q))
+}
+
+// Min returns the shortest duration added, or ErrEmptyDigest if the
+// DurationDigest is empty.
+func (d *DurationDigest) Min() (time.Duration, error) {
+	return toDuration(d.digest.Min())
+}
+
+// Max returns the longest duration added, or ErrEmptyDigest if the
+// DurationDigest is empty.
+func (d *DurationDigest) Max() (time.Duration, error) {
+	return toDuration(d.digest.Max())
+}
+
+// Mean returns the mean duration, or ErrEmptyDigest if the DurationDigest is
+// empty.
+func (d *DurationDigest) Mean() (time.Duration, error) {
+	return toDuration(d.digest.Mean())
+}
+
+// Count returns the number of durations added.
+func (d *DurationDigest) Count() float64 {
+	return d.digest.Count()
+}
+
+// toDuration rounds nanoseconds ns to a Duration. An empty digest has no
+// duration to report, so the error is returned with 0.
+func toDuration(ns float64, err error) (time.Duration, error) {
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(math.Round(ns)), nil
+}
@@ -0,0 +1,69 @@
+package tdigest_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestDurationDigest(t *testing.T) {
+	d := tdigest.NewDuration(20)
+	for i := 1; i <= 1000; i++ {
+		d.Add(time.Duration(i) * time.Millisecond)
+	}
+
+	if got := d.Count(); got != 1000 {
+		t.Errorf("got Count() = %v, want 1000", got)
+	}
+	tcs := []struct {
+		name string
+		f    func() (time.Duration, error)
+		want time.Duration
+	}{{
+		name: "Min",
+		f:    d.Min,
+		want: time.Millisecond,
+	}, {
+		name: "Max",
+		f:    d.Max,
+		want: time.Second,
+	}, {
+		name: "Mean",
+		f:    d.Mean,
+		want: 500500 * time.Microsecond,
+	}, {
+		name: "Quantile(0)",
+		f:    func() (time.Duration, error) { return d.Quantile(0) },
+		want: time.Millisecond,
+	}}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, err := tc.f(); err != nil || got != tc.want {
+				t.Errorf("got %v, %v, want %v", got, err, tc.want)
+			}
+		})
+	}
+
+	p99, err := d.Quantile(0.99)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := p99 - 990*time.Millisecond; diff < -10*time.Millisecond || diff > 10*time.Millisecond {
+		t.Errorf("got Quantile(0.99) = %v, want 990ms +/- 10ms", p99)
+	}
+
+	// Estimates are rounded to the nearest nanosecond.
+	halves := tdigest.NewDuration(20)
+	halves.Add(1)
+	halves.Add(2)
+	if got, err := halves.Mean(); err != nil || got != 2 {
+		t.Errorf("got Mean() = %v, %v for 1ns and 2ns, want 2ns", got, err)
+	}
+
+	empty := tdigest.NewDuration(20)
+	if got, err := empty.Quantile(0.5); !errors.Is(err, tdigest.ErrEmptyDigest) || got != 0 {
+		t.Errorf("got Quantile(0.5) = %v, %v on empty DurationDigest, want 0, %v", got, err, tdigest.ErrEmptyDigest)
+	}
+}
@@ -0,0 +1,106 @@
+package tdigest
+
+import (
+	"fmt"
+	"time"
+)
+
+// epochRingSize is the number of completed epochs an EpochDigest keeps.
+const epochRingSize = 10
+
+// EpochDigest buckets observations into epochs of fixed duration, keeping the
+// most recent completed epochs.
+//
+// No background goroutines are used. Whether the current epoch has ended is
+// only checked when values are added, so an epoch may stay current for longer
+// than its duration if no values are added.
+type EpochDigest struct {
+	epochDuration time.Duration
+
+	// now returns the current time. Replaced in tests.
+	now func() time.Time
+
+	current      *TDigest
+	currentStart time.Time
+
+	// epochs are the completed epochs, from oldest to newest.
+	epochs []*TDigest
+}
+
+// NewEpochDigest returns an EpochDigest with the given compression whose
+// epochs last epochDuration, starting now. It panics if epochDuration is not
+// positive.
+func NewEpochDigest(compression float64, epochDuration time.Duration) *EpochDigest {
+	if epochDuration <= 0 {
+		panic(fmt.Sprintf("tdigest: epoch duration must be positive, got %v", epochDuration))
+	}
+	d := &EpochDigest{
+		epochDuration: epochDuration,
+		now:           time.Now,
+		current:       New(WithCompression(compression)),
+		epochs:        make([]*TDigest, 0, epochRingSize),
+	}
+	d.currentStart = d.now()
+	return d
+}
+
+// Add adds val to the current epoch, first rotating epochs if the current one
+// has ended. It returns ErrInvalidValue if val is NaN or infinite.
+func (d *EpochDigest) Add(val float64) error {
+	d.rotate(d.now())
+	return d.current.Add(val)
+}
+
+// rotate seals the current epoch if it ended before now. Any entire epochs
+// which passed without values being added are recorded as empty.
+func (d *EpochDigest) rotate(now time.Time) {
+	elapsed := int(now.Sub(d.currentStart) / d.epochDuration)
+	if elapsed < 1 {
+		return
+	}
+
+	compression := d.current.Compression()
+	d.push(d.current)
+	for i := 1; i < elapsed && i <= epochRingSize; i++ {
+		d.push(New(WithCompression(compression)))
+	}
+
+	d.current = New(WithCompression(compression))
+	d.currentStart = d.currentStart.Add(time.Duration(elapsed) * d.epochDuration)
+}
+
+// push adds a completed epoch to the ring, evicting the oldest if the ring is
+// full.
+func (d *EpochDigest) push(epoch *TDigest) {
+	if len(d.epochs) == epochRingSize {
+		copy(d.epochs, d.epochs[1:])
+		d.epochs = d.epochs[:epochRingSize-1]
+	}
+	d.epochs = append(d.epochs, epoch)
+}
+
+// Quantile returns the quantile q over the completed epochs and the current
+// epoch, or ErrEmptyDigest if none of them have observations.
+func (d *EpochDigest) Quantile(q float64) (float64, error) {
+	merged := New(WithCompression(d.current.Compression()))
+	for _, epoch := range d.epochs {
+		merged.Accumulate(epoch)
+	}
+	merged.Accumulate(d.current)
+	return merged.Quantile(q)
+}
+
+// EpochCount returns the number of completed epochs, including epochs in which
+// no values were added.
+func (d *EpochDigest) EpochCount() int {
+	return len(d.epochs)
+}
+
+// OldestEpoch returns the oldest completed epoch, or nil if no epochs have
+// completed.
+func (d *EpochDigest) OldestEpoch() *TDigest {
+	if len(d.epochs) == 0 {
+		return nil
+	}
+	return d.epochs[0]
+}
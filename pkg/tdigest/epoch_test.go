@@ -0,0 +1,113 @@
+package tdigest
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually-advanced clock.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newFakeEpochDigest(clock *fakeClock) *EpochDigest {
+	d := NewEpochDigest(100, time.Minute)
+	d.now = clock.now
+	d.currentStart = clock.now()
+	return d
+}
+
+func TestEpochDigest(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	d := newFakeEpochDigest(clock)
+
+	for i := 0; i < 100; i++ {
+		d.Add(1000)
+	}
+	if got := d.EpochCount(); got != 0 {
+		t.Errorf("got EpochCount() = %v within the first epoch, want 0", got)
+	}
+	if got := d.OldestEpoch(); got != nil {
+		t.Errorf("got OldestEpoch() = %v within the first epoch, want nil", got)
+	}
+
+	// Fill every epoch in the ring with values of 1, sealing the epoch of
+	// 1000s. The ring holds the 1000s epoch until it is pushed out.
+	for i := 0; i < epochRingSize; i++ {
+		clock.advance(time.Minute)
+		d.Add(1)
+		if got := d.EpochCount(); got != i+1 {
+			t.Errorf("got EpochCount() = %v, want %v", got, i+1)
+		}
+	}
+	if got := d.OldestEpoch().count; got != 100 {
+		t.Errorf("got oldest epoch with count %v, want %v", got, 100)
+	}
+	if got, err := d.Quantile(0.99); err != nil || got < 500 {
+		t.Errorf("got Quantile(0.99) = %v, want approximately 1000 while its epoch is in the ring", got)
+	}
+
+	clock.advance(time.Minute)
+	d.Add(1)
+	if got := d.EpochCount(); got != epochRingSize {
+		t.Errorf("got EpochCount() = %v, want %v", got, epochRingSize)
+	}
+	if got := d.OldestEpoch().count; got != 1 {
+		t.Errorf("got oldest epoch with count %v, want %v", got, 1)
+	}
+	if got, err := d.Quantile(1); err != nil || got != 1 {
+		t.Errorf("got Quantile(1) = %v, want 1 after evicting epoch of 1000s", got)
+	}
+}
+
+func TestEpochDigest_IdleEpochs(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	d := newFakeEpochDigest(clock)
+
+	d.Add(5)
+	clock.advance(3*time.Minute + time.Second)
+	d.Add(6)
+
+	// The epoch with 5, then two empty epochs.
+	if got := d.EpochCount(); got != 3 {
+		t.Errorf("got EpochCount() = %v, want %v", got, 3)
+	}
+
+	// Once enough time passes, every epoch is evicted.
+	clock.advance(100 * time.Minute)
+	d.Add(7)
+	if got := d.EpochCount(); got != epochRingSize {
+		t.Errorf("got EpochCount() = %v, want %v", got, epochRingSize)
+	}
+	if got, err := d.Quantile(0.5); err != nil || got != 7 {
+		t.Errorf("got Quantile(0.5) = %v, want 7", got)
+	}
+}
+
+func TestEpochDigest_Empty(t *testing.T) {
+	d := NewEpochDigest(100, time.Minute)
+	if _, err := d.Quantile(0.5); err != ErrEmptyDigest {
+		t.Errorf("got Quantile(0.5) error %v, want %v", err, ErrEmptyDigest)
+	}
+}
+
+func TestNewEpochDigest_InvalidDuration(t *testing.T) {
+	for _, duration := range []time.Duration{0, -time.Minute} {
+		t.Run(duration.String(), func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("got no panic")
+				}
+			}()
+			NewEpochDigest(100, duration)
+		})
+	}
+}
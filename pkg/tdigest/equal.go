@@ -0,0 +1,47 @@
+package tdigest
+
+import "math"
+
+// Equal reports whether the TDigest and other have the same compression,
+// count, minimum, maximum, sum, and centroids, with each pair of centroids
+// having exactly the same mean and count. Equal digests therefore give the
+// same results from every query. It is stricter than comparing quantiles, so suits checking
+// that a TDigest survives serialization unchanged.
+func (d *TDigest) Equal(other *TDigest) bool {
+	return d.EqualApprox(other, 0)
+}
+
+// EqualApprox is like Equal, but the minimums, maximums, sums, and centroid
+// means and counts need only be within eps of each other, relative to the
+// larger magnitude of the two. The compression, count, and number of
+// centroids must still be equal.
+func (d *TDigest) EqualApprox(other *TDigest, eps float64) bool {
+	if d == other {
+		return true
+	}
+	if d == nil || other == nil {
+		return false
+	}
+	if d.Compression() != other.Compression() || d.count != other.count || d.nCentroids != other.nCentroids {
+		return false
+	}
+	if d.nCentroids > 0 && !(within(d.min, other.min, eps) && within(d.max, other.max, eps)) {
+		return false
+	}
+	if !within(d.sum, other.sum, eps) {
+		return false
+	}
+	for i := range d.centroids {
+		c, o := &d.centroids[i], &other.centroids[i]
+		if !within(c.mean, o.mean, eps) || !within(c.count, o.count, eps) {
+			return false
+		}
+	}
+	return true
+}
+
+// within reports whether a and b are within eps of each other, relative to the
+// larger of their magnitudes.
+func within(a, b, eps float64) bool {
+	return a == b || math.Abs(a-b) <= eps*math.Max(math.Abs(a), math.Abs(b))
+}
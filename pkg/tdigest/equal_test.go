@@ -0,0 +1,99 @@
+package tdigest_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_Equal(t *testing.T) {
+	digest := newUniformRange(20, 10000, 0, 1, 1)
+
+	added := digest.Clone()
+	added.Add(0.5)
+	shifted, err := tdigest.FromCentroids(20, shiftMeans(digest.Centroids(), 1e-12))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tcs := []struct {
+		name        string
+		other       *tdigest.TDigest
+		equal       bool
+		equalApprox bool
+	}{{
+		name:        "itself",
+		other:       digest,
+		equal:       true,
+		equalApprox: true,
+	}, {
+		name:        "clone",
+		other:       digest.Clone(),
+		equal:       true,
+		equalApprox: true,
+	}, {
+		name:        "same observations",
+		other:       newUniformRange(20, 10000, 0, 1, 1),
+		equal:       true,
+		equalApprox: true,
+	}, {
+		name:        "slightly different means",
+		other:       shifted,
+		equal:       false,
+		equalApprox: true,
+	}, {
+		name:        "different compression",
+		other:       newUniformRange(21, 10000, 0, 1, 1),
+		equal:       false,
+		equalApprox: false,
+	}, {
+		name:        "another observation",
+		other:       added,
+		equal:       false,
+		equalApprox: false,
+	}, {
+		name:        "nil",
+		other:       nil,
+		equal:       false,
+		equalApprox: false,
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := digest.Equal(tc.other); got != tc.equal {
+				t.Errorf("got Equal() = %v, want %v", got, tc.equal)
+			}
+			if got := digest.EqualApprox(tc.other, 1e-9); got != tc.equalApprox {
+				t.Errorf("got EqualApprox() = %v, want %v", got, tc.equalApprox)
+			}
+		})
+	}
+}
+
+func TestTDigest_Equal_Extremes(t *testing.T) {
+	// Both merge into one centroid with mean 2 and count 6, but have different
+	// minimums and maximums.
+	wide := tdigest.New(tdigest.WithCompression(1000))
+	wide.AddAll([]float64{0, 4, 2, 2, 2, 2})
+	narrow := tdigest.New(tdigest.WithCompression(1000))
+	narrow.AddAll([]float64{1, 3, 2, 2, 2, 2})
+	if !reflect.DeepEqual(wide.Centroids(), narrow.Centroids()) {
+		t.Fatalf("got centroids %v and %v, want the same", wide.Centroids(), narrow.Centroids())
+	}
+
+	if wide.Equal(narrow) {
+		t.Error("got Equal() = true for different minimums and maximums")
+	}
+	if wide.EqualApprox(narrow, 0.1) {
+		t.Error("got EqualApprox() = true for different minimums and maximums")
+	}
+}
+
+// shiftMeans returns centroids with each mean increased by a fraction eps.
+func shiftMeans(centroids []tdigest.CentroidData, eps float64) []tdigest.CentroidData {
+	for i := range centroids {
+		centroids[i].Mean *= 1 + eps
+	}
+	return centroids
+}
@@ -0,0 +1,58 @@
+package tdigest_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func ExampleTDigest_Percentile() {
+	// Record request latencies in milliseconds.
+	latencies := tdigest.New()
+	for i := 0; i < 10000; i++ {
+		latencies.Add(float64(i%1000) / 10)
+	}
+
+	p99, err := latencies.Percentile(99)
+	if err != nil {
+		// No requests were recorded.
+		return
+	}
+	fmt.Printf("p99: %.1fms\n", p99)
+
+	ps, _ := latencies.Percentiles([]float64{50, 90, 99.9})
+	fmt.Printf("p50: %.1fms, p90: %.1fms, p99.9: %.1fms\n", ps[0], ps[1], ps[2])
+	// Output:
+	// p99: 98.8ms
+	// p50: 50.0ms, p90: 89.9ms, p99.9: 99.8ms
+}
+
+func ExampleDurationDigest() {
+	latencies := tdigest.NewDuration(tdigest.DefaultCompression)
+
+	// Record the duration of every request in middleware.
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "hello")
+	})
+	timed := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		handler.ServeHTTP(w, r)
+		latencies.Add(time.Since(start))
+	})
+
+	for i := 0; i < 100; i++ {
+		timed.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+
+	p99, err := latencies.Quantile(0.99)
+	if err != nil {
+		// No requests were recorded.
+		return
+	}
+	slowest, _ := latencies.Max()
+	fmt.Printf("%v requests, p99 at most the slowest: %v\n", latencies.Count(), p99 <= slowest)
+	// Output: 100 requests, p99 at most the slowest: true
+}
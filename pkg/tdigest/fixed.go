@@ -0,0 +1,56 @@
+package tdigest
+
+import "fmt"
+
+// NewFixed returns a TDigest which never has more than maxCentroids centroids
+// and doesn't allocate while adding values. It panics if maxCentroids is less
+// than 2.
+//
+// Room for every centroid is preallocated. When a new centroid is needed and all are in
+// use, the two neighboring centroids with the smallest combined count are
+// merged first. So once full, a fixed TDigest trades accuracy for its bounded
+// size.
+//
+// Decoding into a fixed TDigest, such as with UnmarshalBinary, replaces it
+// with an unbounded one.
+func NewFixed(compression float64, maxCentroids int) *TDigest {
+	if maxCentroids < 2 {
+		panic(fmt.Sprintf("tdigest: fixed TDigest needs at least 2 centroids, got %d", maxCentroids))
+	}
+	d := New(WithCompression(compression))
+	d.centroids = make([]centroid, 0, maxCentroids)
+	d.prefixCounts = make([]float64, 0, maxCentroids+1)
+	d.maxCentroids = maxCentroids
+	return d
+}
+
+// makeRoom merges the two neighboring centroids with the smallest combined
+// count, to make room for a new centroid with mean mean at index idx. It
+// returns the index the new centroid should be inserted at instead.
+func (d *TDigest) makeRoom(idx int, mean float64) int {
+	j := 0
+	for i := 1; i < d.nCentroids-1; i++ {
+		if d.centroids[i].count+d.centroids[i+1].count < d.centroids[j].count+d.centroids[j+1].count {
+			j = i
+		}
+	}
+
+	merged, next := &d.centroids[j], d.centroids[j+1]
+	merged.incN(next.mean, next.count)
+	// The merged centroid's cached limit no longer applies.
+	merged.maxCount, merged.nCentroids = 0, 0
+
+	copy(d.centroids[j+1:], d.centroids[j+2:])
+	d.centroids = d.centroids[:d.nCentroids-1]
+	d.nCentroids--
+
+	switch {
+	case idx > j+1:
+		return idx - 1
+	case idx == j+1 && mean < merged.mean:
+		// The new centroid was between the merged ones, so put it on the
+		// correct side of the merged centroid.
+		return j
+	}
+	return idx
+}
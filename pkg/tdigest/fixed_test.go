@@ -0,0 +1,88 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestNewFixed(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.NewFixed(1, 50)
+
+	// Compression 1 would otherwise create a centroid for nearly every value.
+	for i := 0; i < 100000; i++ {
+		digest.Add(r.Float64())
+		if i%1000 == 0 {
+			if got := len(digest.Centroids()); got > 50 {
+				t.Fatalf("got %d centroids after %d values, want at most 50", got, i+1)
+			}
+		}
+	}
+	if err := digest.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(digest.Centroids()); got != 50 {
+		t.Errorf("got %d centroids, want 50", got)
+	}
+	if got := digest.Count(); got != 100000 {
+		t.Errorf("got Count() = %v, want 100000", got)
+	}
+	for _, q := range []float64{0.1, 0.5, 0.9} {
+		if got := mustFloat(digest.Quantile(q)); math.Abs(got-q) > 0.05 {
+			t.Errorf("got Quantile(%v) = %v, want %v +/- 0.05", q, got, q)
+		}
+	}
+}
+
+func TestNewFixed_Allocs(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.NewFixed(1, 50)
+
+	allocs := testing.AllocsPerRun(100000, func() {
+		digest.Add(r.NormFloat64())
+	})
+	if allocs != 0 {
+		t.Errorf("got %v allocations per Add, want 0", allocs)
+	}
+}
+
+func TestNewFixed_ResetCloneRecompress(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.NewFixed(1, 50)
+	for i := 0; i < 1000; i++ {
+		digest.Add(r.Float64())
+	}
+
+	// Each keeps the TDigest fixed, with all its centroids available.
+	clone := digest.Clone()
+	recompressed := digest.Clone()
+	recompressed.Recompress()
+	digest.Reset()
+
+	for _, d := range []*tdigest.TDigest{digest, clone, recompressed} {
+		allocs := testing.AllocsPerRun(10000, func() {
+			d.Add(r.Float64())
+		})
+		if allocs != 0 {
+			t.Errorf("got %v allocations per Add, want 0", allocs)
+		}
+		if got := len(d.Centroids()); got > 50 {
+			t.Errorf("got %d centroids, want at most 50", got)
+		}
+		if err := d.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestNewFixed_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic")
+		}
+	}()
+	tdigest.NewFixed(20, 1)
+}
@@ -0,0 +1,28 @@
+package tdigest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StringRepr lists the TDigest's centroids one per line, with means written to
+// precision digits after the decimal point and counts as integers.
+func (d *TDigest) StringRepr(precision int) string {
+	sb := strings.Builder{}
+	for _, c := range d.centroids {
+		fmt.Fprintf(&sb, "mean: %.*f, count: %d\n", precision, c.mean, int(c.count))
+	}
+	return sb.String()
+}
+
+// DebugString is StringRepr with enough precision to distinguish nearby means.
+func (d *TDigest) DebugString() string {
+	return d.StringRepr(10)
+}
+
+// FormatCentroid formats c in the same layout as StringRepr, using meanFmt and
+// countFmt as the fmt verbs for its mean and count. Both receive a float64, so
+// for example "%.2e" and "%.0f" are valid but "%d" is not.
+func FormatCentroid(c CentroidData, meanFmt, countFmt string) string {
+	return fmt.Sprintf("mean: "+meanFmt+", count: "+countFmt, c.Mean, c.Count)
+}
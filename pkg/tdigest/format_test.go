@@ -0,0 +1,79 @@
+package tdigest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_StringRepr(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(1))
+	digest.Add(0.1)
+	digest.Add(2.5)
+
+	tcs := []struct {
+		name      string
+		precision int
+		want      string
+	}{{
+		name:      "integer means",
+		precision: 0,
+		want:      "mean: 0, count: 1\nmean: 2, count: 1\n",
+	}, {
+		name:      "default precision",
+		precision: 4,
+		want:      "mean: 0.1000, count: 1\nmean: 2.5000, count: 1\n",
+	}, {
+		name:      "float64 precision",
+		precision: 15,
+		want:      "mean: 0.100000000000000, count: 1\nmean: 2.500000000000000, count: 1\n",
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := digest.StringRepr(tc.precision); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	if got, want := digest.String(), digest.StringRepr(4); got != want {
+		t.Errorf("got String() = %q, want %q", got, want)
+	}
+	if got, want := digest.DebugString(), digest.StringRepr(10); got != want {
+		t.Errorf("got DebugString() = %q, want %q", got, want)
+	}
+}
+
+func TestTDigest_StringRepr_Empty(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(100))
+
+	if got := digest.StringRepr(4); got != "" {
+		t.Errorf("got %q, want empty", got)
+	}
+	if got := digest.DebugString(); got != "" {
+		t.Errorf("got %q, want empty", got)
+	}
+}
+
+func TestTDigest_StringRepr_SmallValues(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(100))
+	digest.Add(1.23456789e-7)
+
+	if got := digest.String(); !strings.HasPrefix(got, "mean: 0.0000,") {
+		t.Fatalf("got %q, want mean rounded to zero", got)
+	}
+	if got, want := digest.DebugString(), "mean: 0.0000001235, count: 1\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormatCentroid(t *testing.T) {
+	c := tdigest.CentroidData{Mean: 1234.5, Count: 3}
+
+	got := tdigest.FormatCentroid(c, "%.2e", "%.0f")
+	if want := "mean: 1.23e+03, count: 3"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,19 @@
+package tdigest
+
+import "encoding/gob"
+
+var (
+	_ gob.GobEncoder = (*TDigest)(nil)
+	_ gob.GobDecoder = (*TDigest)(nil)
+)
+
+// GobEncode encodes the TDigest for encoding/gob in the same format as
+// MarshalBinary.
+func (d *TDigest) GobEncode() ([]byte, error) {
+	return d.MarshalBinary()
+}
+
+// GobDecode replaces the TDigest with one encoded by GobEncode.
+func (d *TDigest) GobDecode(b []byte) error {
+	return d.UnmarshalBinary(b)
+}
@@ -0,0 +1,58 @@
+package tdigest_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_Gob(t *testing.T) {
+	digest := newUniformRange(20, 50000, 0, 1, 1)
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(digest); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded *tdigest.TDigest
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := decoded.Count(), digest.Count(); got != want {
+		t.Errorf("got Count() = %v, want %v", got, want)
+	}
+	for _, q := range []float64{0.001, 0.01, 0.25, 0.5, 0.75, 0.99, 0.999} {
+		want := mustFloat(digest.Quantile(q))
+		if got := mustFloat(decoded.Quantile(q)); math.Abs(got-want) > 0.01*want {
+			t.Errorf("got Quantile(%v) = %v, want %v +/- 1%%", q, got, want)
+		}
+	}
+}
+
+func TestTDigest_Gob_Struct(t *testing.T) {
+	type state struct {
+		Name   string
+		Digest *tdigest.TDigest
+	}
+	want := state{Name: "latency", Digest: newUniformRange(20, 1000, 0, 1, 1)}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(want); err != nil {
+		t.Fatal(err)
+	}
+	var got state
+	if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Name != want.Name {
+		t.Errorf("got Name = %q, want %q", got.Name, want.Name)
+	}
+	if got.Digest.Count() != want.Digest.Count() {
+		t.Errorf("got Count() = %v, want %v", got.Digest.Count(), want.Digest.Count())
+	}
+}
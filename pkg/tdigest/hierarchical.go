@@ -0,0 +1,86 @@
+package tdigest
+
+import (
+	"math"
+	"sort"
+)
+
+// hierarchicalBufferFactor is the default buffer size of a HierarchicalTDigest
+// as a multiple of its compression.
+const hierarchicalBufferFactor = 10
+
+// HierarchicalTDigest absorbs values into a buffer and periodically merges the
+// buffer into a TDigest with the target compression.
+//
+// The buffer holds raw values, the limit of a buffer TDigest with one value
+// per centroid. When it is full, it is sorted and merged with AddSorted, so no
+// value needs a nearest-centroid search and the merged TDigest always has a
+// LoadFactor of at most 1. As with Add, smaller compressions keep more centroids and
+// are more accurate.
+type HierarchicalTDigest struct {
+	compression float64
+
+	buffer []float64
+	merged *TDigest
+}
+
+// NewHierarchical returns a HierarchicalTDigest with the given compression
+// which merges its buffer after every bufferSize values. If bufferSize is not
+// positive, the buffer holds 10 times compression values.
+func NewHierarchical(compression float64, bufferSize int) *HierarchicalTDigest {
+	if bufferSize <= 0 {
+		bufferSize = int(math.Max(1, math.Ceil(hierarchicalBufferFactor*compression)))
+	}
+	return &HierarchicalTDigest{
+		compression: compression,
+		buffer:      make([]float64, 0, bufferSize),
+		merged:      New(WithCompression(compression)),
+	}
+}
+
+// Add adds val to the buffer, merging the buffer if it is full. It returns
+// ErrInvalidValue if val is NaN or infinite.
+func (d *HierarchicalTDigest) Add(val float64) error {
+	if !isValid(val) {
+		return ErrInvalidValue
+	}
+	d.buffer = append(d.buffer, val)
+	if len(d.buffer) == cap(d.buffer) {
+		d.flush()
+	}
+	return nil
+}
+
+// flush merges the buffered values into the merged TDigest and empties the
+// buffer.
+func (d *HierarchicalTDigest) flush() {
+	if len(d.buffer) == 0 {
+		return
+	}
+	sort.Float64s(d.buffer)
+	// The buffer only holds valid values, so AddSorted can't fail.
+	_ = d.merged.AddSorted(d.buffer)
+	d.buffer = d.buffer[:0]
+}
+
+// Merge adds the observations of other to d. other is unchanged.
+func (d *HierarchicalTDigest) Merge(other *HierarchicalTDigest) {
+	d.flush()
+	d.merged = MergeAll([]*TDigest{d.merged, other.merged}, d.compression)
+
+	buffer := append([]float64(nil), other.buffer...)
+	sort.Float64s(buffer)
+	_ = d.merged.AddSorted(buffer)
+}
+
+// Quantile returns the quantile q of all observations, or ErrEmptyDigest if
+// there are none. Any buffered values are merged first.
+func (d *HierarchicalTDigest) Quantile(q float64) (float64, error) {
+	d.flush()
+	return d.merged.Quantile(q)
+}
+
+// Count returns the number of observations, including buffered values.
+func (d *HierarchicalTDigest) Count() float64 {
+	return d.merged.Count() + float64(len(d.buffer))
+}
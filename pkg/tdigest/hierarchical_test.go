@@ -0,0 +1,139 @@
+package tdigest
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestHierarchicalTDigest(t *testing.T) {
+	d := NewHierarchical(20, 0)
+	if got := cap(d.buffer); got != 200 {
+		t.Fatalf("got buffer size %v, want %v", got, 200)
+	}
+	if _, err := d.Quantile(0.5); err != ErrEmptyDigest {
+		t.Errorf("got error %v for empty digest, want %v", err, ErrEmptyDigest)
+	}
+	if err := d.Add(math.NaN()); err != ErrInvalidValue {
+		t.Errorf("got error %v adding NaN, want %v", err, ErrInvalidValue)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	vals := make([]float64, 10050)
+	for i := range vals {
+		vals[i] = r.NormFloat64()
+		d.Add(vals[i])
+	}
+	sort.Float64s(vals)
+
+	// The last 50 values are still buffered.
+	if got := len(d.buffer); got != 50 {
+		t.Errorf("got %v buffered values, want %v", got, 50)
+	}
+	if got := d.Count(); got != float64(len(vals)) {
+		t.Errorf("got Count() = %v, want %v", got, len(vals))
+	}
+
+	tolerance := 0.01 * (vals[len(vals)-1] - vals[0])
+	for _, q := range []float64{0.01, 0.1, 0.5, 0.9, 0.99} {
+		got, err := d.Quantile(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := sampleQuantile(vals, q); math.Abs(got-want) > tolerance {
+			t.Errorf("got Quantile(%v) = %v, want %v +/- %v", q, got, want, tolerance)
+		}
+	}
+	if got := len(d.buffer); got != 0 {
+		t.Errorf("got %v buffered values after Quantile, want 0", got)
+	}
+}
+
+func TestHierarchicalTDigest_Merge(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	a, b := NewHierarchical(20, 0), NewHierarchical(20, 0)
+	vals := make([]float64, 20100)
+	for i := range vals {
+		vals[i] = r.NormFloat64()
+		if i%2 == 0 {
+			a.Add(vals[i])
+		} else {
+			b.Add(vals[i])
+		}
+	}
+	sort.Float64s(vals)
+
+	a.Merge(b)
+
+	if got := a.Count(); got != 20100 {
+		t.Errorf("got Count() = %v, want %v", got, 20100)
+	}
+	// b keeps its buffered values.
+	if got := b.Count(); got != 10050 || len(b.buffer) != 50 {
+		t.Errorf("got Count() = %v with %v buffered values for merged digest, want %v with 50 buffered", got, len(b.buffer), 10050)
+	}
+
+	tolerance := 0.01 * (vals[len(vals)-1] - vals[0])
+	for _, q := range []float64{0.01, 0.1, 0.5, 0.9, 0.99} {
+		got, err := a.Quantile(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := sampleQuantile(vals, q); math.Abs(got-want) > tolerance {
+			t.Errorf("got Quantile(%v) = %v, want %v +/- %v", q, got, want, tolerance)
+		}
+	}
+}
+
+// BenchmarkHierarchicalTDigest compares adding normally distributed values to
+// a HierarchicalTDigest and to a TDigest at compression 2000, reporting the
+// rate of adds and the largest relative error over a range of quantiles.
+func BenchmarkHierarchicalTDigest(b *testing.B) {
+	const (
+		n           = 1000000
+		compression = 2000
+	)
+
+	r := rand.New(rand.NewSource(1))
+	vals := make([]float64, n)
+	for i := range vals {
+		vals[i] = r.NormFloat64()
+	}
+	sorted := append([]float64(nil), vals...)
+	sort.Float64s(sorted)
+
+	qs := []float64{0.001, 0.01, 0.1, 0.5, 0.9, 0.99, 0.999}
+	reportError := func(b *testing.B, quantile func(q float64) (float64, error)) {
+		var maxRel float64
+		for _, q := range qs {
+			want := sampleQuantile(sorted, q)
+			got, _ := quantile(q)
+			maxRel = math.Max(maxRel, math.Abs(got-want)/math.Abs(want))
+		}
+		b.ReportMetric(maxRel, "max-rel-err")
+	}
+
+	b.Run("Hierarchical", func(b *testing.B) {
+		var d *HierarchicalTDigest
+		for i := 0; i < b.N; i++ {
+			d = NewHierarchical(compression, 0)
+			for _, val := range vals {
+				d.Add(val)
+			}
+		}
+		b.ReportMetric(float64(n)*float64(b.N)/b.Elapsed().Seconds(), "adds/s")
+		reportError(b, d.Quantile)
+	})
+	b.Run("TDigest", func(b *testing.B) {
+		var d *TDigest
+		for i := 0; i < b.N; i++ {
+			d = New(WithCompression(compression))
+			for _, val := range vals {
+				d.Add(val)
+			}
+		}
+		b.ReportMetric(float64(n)*float64(b.N)/b.Elapsed().Seconds(), "adds/s")
+		reportError(b, d.Quantile)
+	})
+}
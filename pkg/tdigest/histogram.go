@@ -0,0 +1,68 @@
+package tdigest
+
+import (
+	"fmt"
+	"math"
+)
+
+// Histogram returns the estimated number of observations in each bucket
+// defined by boundaries, for exporting to systems which expect explicit
+// buckets. Element i of the result counts the observations in
+// (boundaries[i-1], boundaries[i]], where boundaries[-1] is -∞ and
+// boundaries[len(boundaries)] is +∞, so the result has len(boundaries)+1
+// elements. The counts sum to Count. An empty TDigest has all-zero counts.
+//
+// Histogram panics if boundaries are NaN or not sorted in increasing order.
+//
+// The counts are estimated from the CDF at each boundary.
+func (d *TDigest) Histogram(boundaries []float64) []float64 {
+	checkBoundaries(boundaries)
+
+	result := make([]float64, len(boundaries)+1)
+	if d.nCentroids == 0 {
+		return result
+	}
+
+	var prev float64
+	for i, b := range boundaries {
+		rank := d.cdf(b) * d.count
+		result[i] = rank - prev
+		prev = rank
+	}
+	result[len(boundaries)] = d.count - prev
+	return result
+}
+
+// PrometheusHistogram returns the estimated number of observations less than
+// or equal to each of buckets, as in the cumulative "le" buckets of a
+// Prometheus histogram, and the sum of the observations. The +Inf bucket is
+// Count. An empty TDigest has all-zero counts and a sum of 0.
+//
+// PrometheusHistogram panics if buckets are NaN or not sorted in increasing
+// order.
+func (d *TDigest) PrometheusHistogram(buckets []float64) (cumCounts []float64, sum float64) {
+	checkBoundaries(buckets)
+
+	cumCounts = make([]float64, len(buckets))
+	if d.nCentroids == 0 {
+		return cumCounts, 0
+	}
+	for i, b := range buckets {
+		cumCounts[i] = d.cdf(b) * d.count
+	}
+	return cumCounts, d.sum
+}
+
+// checkBoundaries panics if boundaries are NaN or not sorted in increasing
+// order.
+func checkBoundaries(boundaries []float64) {
+	for i, b := range boundaries {
+		if math.IsNaN(b) {
+			panic(fmt.Sprintf("tdigest: histogram boundary %d is NaN", i))
+		}
+		if i > 0 && b < boundaries[i-1] {
+			panic(fmt.Sprintf("tdigest: histogram boundary %d, %v, is less than previous boundary %v",
+				i, b, boundaries[i-1]))
+		}
+	}
+}
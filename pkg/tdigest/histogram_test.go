@@ -0,0 +1,115 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_Histogram(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.New(tdigest.WithCompression(5))
+	boundaries := []float64{-3, -2, -1, -0.5, 0, 0.5, 1, 2, 3}
+	want := make([]float64, len(boundaries)+1)
+	for i := 0; i < 100000; i++ {
+		val := r.NormFloat64()
+		digest.Add(val)
+
+		bucket := 0
+		for bucket < len(boundaries) && val > boundaries[bucket] {
+			bucket++
+		}
+		want[bucket]++
+	}
+
+	got := digest.Histogram(boundaries)
+	if len(got) != len(want) {
+		t.Fatalf("got %d buckets, want %d", len(got), len(want))
+	}
+	var sum float64
+	for i := range got {
+		if math.Abs(got[i]-want[i]) > 0.005*digest.Count() {
+			t.Errorf("got bucket %d count %v, want %v", i, got[i], want[i])
+		}
+		sum += got[i]
+	}
+	if math.Abs(sum-digest.Count()) > 1e-9*digest.Count() {
+		t.Errorf("got bucket counts summing to %v, want %v", sum, digest.Count())
+	}
+}
+
+func TestTDigest_Histogram_Edges(t *testing.T) {
+	digest := newUniformRange(20, 1000, 0, 1, 1)
+
+	// Without boundaries, the only bucket holds everything.
+	if got := digest.Histogram(nil); len(got) != 1 || got[0] != 1000 {
+		t.Errorf("got Histogram(nil) = %v, want [1000]", got)
+	}
+
+	// Repeated boundaries give empty buckets.
+	if got := digest.Histogram([]float64{0.5, 0.5}); got[1] != 0 {
+		t.Errorf("got Histogram([0.5 0.5]) = %v, want an empty middle bucket", got)
+	}
+
+	empty := tdigest.New()
+	for i, count := range empty.Histogram([]float64{0, 1}) {
+		if count != 0 {
+			t.Errorf("got bucket %d count %v for empty TDigest, want 0", i, count)
+		}
+	}
+
+	for _, boundaries := range [][]float64{{1, 0}, {0, math.NaN()}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("got no panic for Histogram(%v)", boundaries)
+				}
+			}()
+			digest.Histogram(boundaries)
+		}()
+	}
+}
+
+func TestTDigest_PrometheusHistogram(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.New(tdigest.WithCompression(5))
+	var want float64
+	for i := 0; i < 100000; i++ {
+		val := r.NormFloat64() + 10
+		digest.Add(val)
+		want += val
+	}
+	digest.AddWeighted(1, 10)
+	want += 10
+
+	buckets := []float64{8, 9, 10, 11, 12, math.Inf(1)}
+	cumCounts, sum := digest.PrometheusHistogram(buckets)
+	if math.Abs(sum-want) > 1e-9*want {
+		t.Errorf("got sum %v, want %v", sum, want)
+	}
+
+	// Cumulative counts are running totals of the histogram buckets.
+	var total float64
+	for i, count := range digest.Histogram(buckets)[:len(buckets)] {
+		total += count
+		if math.Abs(cumCounts[i]-total) > 1e-6 {
+			t.Errorf("got cumulative count %v for le=%v, want %v", cumCounts[i], buckets[i], total)
+		}
+	}
+	if got := cumCounts[len(cumCounts)-1]; got != digest.Count() {
+		t.Errorf("got cumulative count %v for le=+Inf, want %v", got, digest.Count())
+	}
+
+	digest.Reset()
+	digest.Add(3)
+	if _, sum := digest.PrometheusHistogram(nil); sum != 3 {
+		t.Errorf("got sum %v after Reset, want 3", sum)
+	}
+
+	empty := tdigest.New()
+	if cumCounts, sum := empty.PrometheusHistogram([]float64{0}); cumCounts[0] != 0 || sum != 0 {
+		t.Errorf("got %v, %v for empty TDigest, want [0], 0", cumCounts, sum)
+	}
+}
@@ -0,0 +1,73 @@
+package tdigest
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// AddFrom adds a value from each line of r, such as the output of a sensor or
+// a column cut from a log file, until r is exhausted. Surrounding whitespace
+// is ignored, and so are blank lines. Lines which aren't a valid value, as for
+// Add, are skipped.
+//
+// It returns the number of values added and lines skipped, and any error from
+// reading r other than io.EOF, after which no more lines are read.
+func (d *TDigest) AddFrom(r io.Reader) (added, skipped int64, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if d.addString(line) {
+			added++
+		} else {
+			skipped++
+		}
+	}
+	return added, skipped, scanner.Err()
+}
+
+// AddFromCSV adds the value in column col, counting from 0, of each row of the
+// CSV read from r. Rows may have different numbers of columns. Rows where col
+// isn't a valid value, as for Add, or which have no column col are skipped, so
+// a header row is skipped.
+//
+// It returns the number of values added and rows skipped, and any error from
+// reading r or parsing it as CSV, after which no more rows are read. It panics
+// if col is negative.
+func (d *TDigest) AddFromCSV(r io.Reader, col int) (added, skipped int64, err error) {
+	if col < 0 {
+		panic(fmt.Sprintf("tdigest: CSV column must not be negative, got %d", col))
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return added, skipped, nil
+		} else if err != nil {
+			return added, skipped, fmt.Errorf("tdigest: reading CSV: %w", err)
+		}
+
+		if col < len(row) && d.addString(strings.TrimSpace(row[col])) {
+			added++
+		} else {
+			skipped++
+		}
+	}
+}
+
+// addString adds the value s represents, and returns whether it is a valid
+// value.
+func (d *TDigest) addString(s string) bool {
+	val, err := strconv.ParseFloat(s, 64)
+	return err == nil && d.Add(val) == nil
+}
@@ -0,0 +1,78 @@
+package tdigest_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_AddFrom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var sb strings.Builder
+	want := tdigest.New(tdigest.WithCompression(20))
+	for i := 0; i < 10000; i++ {
+		val := r.NormFloat64()
+		want.Add(val)
+		fmt.Fprintf(&sb, "%v\n", val)
+	}
+	// Malformed and invalid lines are skipped, and blank lines ignored.
+	sb.WriteString("\nfoo\nNaN\n  \n1e400\n")
+
+	got := tdigest.New(tdigest.WithCompression(20))
+	added, skipped, err := got.AddFrom(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 10000 || skipped != 3 {
+		t.Errorf("got %d added and %d skipped, want 10000 and 3", added, skipped)
+	}
+	if !got.Equal(want) {
+		t.Error("got different centroids from AddFrom than from Add")
+	}
+}
+
+func TestTDigest_AddFrom_ReadError(t *testing.T) {
+	r := io.MultiReader(strings.NewReader("1\n2\n"), iotest.ErrReader(io.ErrUnexpectedEOF))
+	added, _, err := tdigest.New().AddFrom(r)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got error %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if added != 2 {
+		t.Errorf("got %d added before the error, want 2", added)
+	}
+}
+
+func TestTDigest_AddFromCSV(t *testing.T) {
+	const input = `name,latency,size
+a,1.5,100
+b,2.5
+c,,300
+d,oops,400
+"e, quoted", 3.5 ,500
+f
+`
+	digest := tdigest.New(tdigest.WithCompression(20))
+	added, skipped, err := digest.AddFromCSV(strings.NewReader(input), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The header, the empty and malformed values, and the row without a
+	// latency are skipped.
+	if added != 3 || skipped != 4 {
+		t.Errorf("got %d added and %d skipped, want 3 and 4", added, skipped)
+	}
+	if got := mustFloat(digest.Mean()); got != 2.5 {
+		t.Errorf("got Mean() = %v, want 2.5", got)
+	}
+
+	_, _, err = tdigest.New().AddFromCSV(strings.NewReader("1\n\"unterminated\n"), 0)
+	if err == nil {
+		t.Error("got no error reading malformed CSV")
+	}
+}
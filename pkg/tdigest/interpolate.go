@@ -0,0 +1,84 @@
+package tdigest
+
+import "fmt"
+
+// Interpolate returns a TDigest which linearly interpolates between lo and hi,
+// with weight q toward hi. Interpolate(0, lo, hi) is a copy of lo and
+// Interpolate(1, lo, hi) is a copy of hi.
+//
+// Centroids are paired by index, so each interpolated centroid's mean and
+// count are the weighted averages of the paired centroids. If lo and hi have
+// different numbers of centroids, both are first resampled into the same
+// number of equally-weighted centroids.
+//
+// Interpolate panics if q is not in [0, 1] or if lo and hi have different
+// compressions.
+func Interpolate(q float64, lo, hi *TDigest) *TDigest {
+	if !(q >= 0 && q <= 1) {
+		panic(fmt.Sprintf("tdigest: interpolation weight must be in [0, 1], got %v", q))
+	}
+	if lo.Compression() != hi.Compression() {
+		panic(fmt.Sprintf("tdigest: cannot interpolate between compressions %v and %v", lo.Compression(), hi.Compression()))
+	}
+
+	switch {
+	case q == 0:
+		return lo.Clone()
+	case q == 1:
+		return hi.Clone()
+	case lo.nCentroids == 0:
+		return hi.scaled(q)
+	case hi.nCentroids == 0:
+		return lo.scaled(1 - q)
+	}
+
+	loCentroids, hiCentroids := lo.centroids, hi.centroids
+	if lo.nCentroids != hi.nCentroids {
+		// Resample both so paired centroids are at the same quantiles.
+		n := lo.nCentroids
+		if hi.nCentroids > n {
+			n = hi.nCentroids
+		}
+		loCentroids = lo.resample(n)
+		hiCentroids = hi.resample(n)
+	}
+
+	// Interpolating between two sorted lists of centroids keeps them sorted.
+	centroids := make([]centroid, len(loCentroids))
+	for i, l := range loCentroids {
+		h := hiCentroids[i]
+		centroids[i] = centroid{
+			mean:  (1-q)*l.mean + q*h.mean,
+			count: (1-q)*l.count + q*h.count,
+		}
+	}
+
+	result := New(WithCompression(lo.Compression()))
+	result.setCentroids(centroids)
+	return result
+}
+
+// scaled returns a copy of the TDigest with every centroid's count multiplied
+// by weight.
+func (d *TDigest) scaled(weight float64) *TDigest {
+	result := d.Clone()
+	for i := range result.centroids {
+		c := &result.centroids[i]
+		c.count *= weight
+		c.maxCount = 0
+	}
+	result.setCentroids(result.centroids)
+	return result
+}
+
+// resample returns n equally-weighted centroids approximating the
+// distribution.
+func (d *TDigest) resample(n int) []centroid {
+	centroids := make([]centroid, n)
+	count := d.count / float64(n)
+	for i := range centroids {
+		q := (float64(i) + 0.5) / float64(n)
+		centroids[i] = centroid{mean: d.quantile(q), count: count}
+	}
+	return centroids
+}
@@ -0,0 +1,76 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func newUniformRange(compression float64, n int, lo, hi float64, seed int64) *tdigest.TDigest {
+	r := rand.New(rand.NewSource(seed))
+	digest := tdigest.New(tdigest.WithCompression(compression))
+	for i := 0; i < n; i++ {
+		digest.Add(lo + (hi-lo)*r.Float64())
+	}
+	return digest
+}
+
+func TestInterpolate(t *testing.T) {
+	a := newUniformRange(100, 100000, 0, 1, 1)
+	b := newUniformRange(100, 50000, 1, 2, 2)
+
+	if got, want := tdigest.Interpolate(0, a, b).String(), a.String(); got != want {
+		t.Errorf("got Interpolate(0, a, b) = %v, want %v", got, want)
+	}
+	if got, want := tdigest.Interpolate(1, a, b).String(), b.String(); got != want {
+		t.Errorf("got Interpolate(1, a, b) = %v, want %v", got, want)
+	}
+
+	mid := tdigest.Interpolate(0.5, a, b)
+	for i := 1; i < 10; i++ {
+		q := float64(i) / 10
+		// Halfway between uniform [0, 1] and uniform [1, 2] is uniform [0.5, 1.5].
+		if got, want := mustFloat(mid.Quantile(q)), 0.5+q; math.Abs(got-want) > 0.02 {
+			t.Errorf("Quantile(%v): got %v, want %v", q, got, want)
+		}
+	}
+}
+
+func TestInterpolate_CompressionMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic interpolating between different compressions")
+		}
+	}()
+	tdigest.Interpolate(0.5, tdigest.New(tdigest.WithCompression(100)), tdigest.New(tdigest.WithCompression(200)))
+}
+
+func TestInterpolate_InvalidWeight(t *testing.T) {
+	a := newUniformRange(100, 1000, 0, 1, 1)
+	b := newUniformRange(100, 1000, 1, 2, 2)
+	for _, q := range []float64{-0.1, 1.1, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("got no panic for Interpolate(%v, a, b)", q)
+				}
+			}()
+			tdigest.Interpolate(q, a, b)
+		}()
+	}
+}
+
+func TestInterpolate_ZeroValue(t *testing.T) {
+	// The zero TDigest has DefaultCompression, so it matches New().
+	var zero tdigest.TDigest
+	zero.Add(1)
+	other := tdigest.New()
+	other.Add(3)
+
+	mid := tdigest.Interpolate(0.5, &zero, other)
+	if got, want := mustFloat(mid.Quantile(0.5)), 2.0; got != want {
+		t.Errorf("got Quantile(0.5) = %v, want %v", got, want)
+	}
+}
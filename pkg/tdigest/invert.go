@@ -0,0 +1,41 @@
+package tdigest
+
+import (
+	"log"
+	"sort"
+)
+
+// Invert returns a new TDigest of the distribution of 1/X, where X is the
+// distribution summarized by the TDigest. For example, the distribution of
+// throughput from a distribution of latencies.
+//
+// Each centroid's mean is replaced by its reciprocal and the counts are
+// unchanged. Centroids with a mean of exactly zero have no reciprocal, so they
+// are skipped with a logged warning.
+//
+// Invert is most meaningful for distributions over positive values.
+func (d *TDigest) Invert() *TDigest {
+	centroids := make([]centroid, 0, d.nCentroids)
+	for _, c := range d.centroids {
+		if c.mean == 0 {
+			log.Printf("tdigest: skipping centroid with mean 0 and count %v: its reciprocal is undefined", c.count)
+			continue
+		}
+		centroids = append(centroids, centroid{mean: 1.0 / c.mean, count: c.count})
+	}
+
+	// Inverting flips the order of centroids with the same sign, so they must be
+	// re-sorted.
+	sort.Slice(centroids, func(i, j int) bool {
+		return centroids[i].mean < centroids[j].mean
+	})
+
+	result := New(WithCompression(d.Compression()))
+	result.setCentroids(centroids)
+	return result
+}
+
+// Reciprocal is an alias of Invert.
+func (d *TDigest) Reciprocal() *TDigest {
+	return d.Invert()
+}
@@ -0,0 +1,61 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_Invert(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.New(tdigest.WithCompression(100))
+	for i := 0; i < 100000; i++ {
+		digest.Add(math.Exp(r.NormFloat64()))
+	}
+
+	inverted := digest.Invert()
+	for i := 1; i < 10; i++ {
+		q := float64(i) / 10
+		got := mustFloat(inverted.Quantile(q))
+		want := 1.0 / mustFloat(digest.Quantile(1-q))
+		if math.Abs(got-want) > 0.02*want {
+			t.Errorf("mustFloat(Invert().Quantile(%v)): got %v, want %v", q, got, want)
+		}
+	}
+
+	reciprocal := digest.Reciprocal()
+	if got, want := reciprocal.String(), inverted.String(); got != want {
+		t.Errorf("got Reciprocal() = %v, want %v", got, want)
+	}
+}
+
+func TestTDigest_Invert_Zero(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(1))
+	for _, val := range []float64{0, 4} {
+		digest.Add(val)
+	}
+
+	inverted := digest.Invert()
+	if got, want := inverted.String(), "mean: 0.2500, count: 1\n"; got != want {
+		t.Errorf("got Invert() = %q, want %q", got, want)
+	}
+}
+
+func TestTDigest_Invert_ZeroValue(t *testing.T) {
+	// The zero TDigest is ready to use, so it can be inverted.
+	var digest tdigest.TDigest
+	if got := digest.Invert(); got.Count() != 0 {
+		t.Errorf("got Count() = %v, want 0", got.Count())
+	}
+
+	digest.Add(2)
+	inverted := digest.Invert()
+	if got, want := inverted.Compression(), float64(tdigest.DefaultCompression); got != want {
+		t.Errorf("got Compression() = %v, want %v", got, want)
+	}
+	if got, want := mustFloat(inverted.Quantile(0.5)), 0.5; got != want {
+		t.Errorf("got Quantile(0.5) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package tdigest
+
+import "encoding/json"
+
+var (
+	_ json.Marshaler   = (*TDigest)(nil)
+	_ json.Unmarshaler = (*TDigest)(nil)
+)
+
+// jsonTDigest is the JSON form of a TDigest. Cached values are left out and
+// recomputed when unmarshalling.
+type jsonTDigest struct {
+	Compression float64 `json:"compression"`
+	Count       float64 `json:"count"`
+	// Sum is nil in JSON written before the sum was kept.
+	Sum       *float64       `json:"sum"`
+	Centroids []jsonCentroid `json:"centroids"`
+}
+
+type jsonCentroid struct {
+	Mean  float64 `json:"mean"`
+	Count float64 `json:"count"`
+}
+
+// MarshalJSON encodes the TDigest as its compression, count, sum, and
+// centroids.
+func (d *TDigest) MarshalJSON() ([]byte, error) {
+	sum := d.sum
+	j := jsonTDigest{
+		Compression: d.Compression(),
+		Count:       d.count,
+		Sum:         &sum,
+		Centroids:   make([]jsonCentroid, len(d.centroids)),
+	}
+	for i, c := range d.centroids {
+		j.Centroids[i] = jsonCentroid{Mean: c.mean, Count: c.count}
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON replaces the TDigest with one encoded by MarshalJSON. The
+// centroids must be sorted by increasing mean. If the sum is missing, it is
+// computed from the centroids.
+func (d *TDigest) UnmarshalJSON(b []byte) error {
+	var j jsonTDigest
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+
+	if err := validateCompression(j.Compression); err != nil {
+		return err
+	}
+	centroids := make([]centroid, len(j.Centroids))
+	for i, c := range j.Centroids {
+		centroids[i] = centroid{mean: c.Mean, count: c.Count}
+	}
+	if err := validateCentroids(centroids, j.Count); err != nil {
+		return err
+	}
+
+	d.reset()
+	d.compression = j.Compression
+	d.setCentroids(centroids)
+	if j.Sum != nil {
+		d.sum = *j.Sum
+	}
+	return nil
+}
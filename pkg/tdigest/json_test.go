@@ -0,0 +1,74 @@
+package tdigest_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_MarshalJSON(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(1))
+	digest.Add(1)
+	digest.Add(2)
+
+	b, err := json.Marshal(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"compression":1,"count":2,"sum":3,"centroids":[{"mean":1,"count":1},{"mean":2,"count":1}]}`
+	if got := string(b); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestTDigest_UnmarshalJSON(t *testing.T) {
+	digest := newUniformRange(20, 10000, 0, 1, 1)
+
+	b, err := json.Marshal(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := tdigest.New(tdigest.WithCompression(100))
+	if err = json.Unmarshal(b, got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.Equal(digest) {
+		t.Errorf("got centroids %v, want %v", got, digest)
+	}
+	for i := 0; i <= 100; i++ {
+		q := float64(i) / 100
+		if got, want := mustFloat(got.Quantile(q)), mustFloat(digest.Quantile(q)); got != want {
+			t.Errorf("got Quantile(%v) = %v, want %v", q, got, want)
+		}
+	}
+}
+
+func TestTDigest_UnmarshalJSON_Invalid(t *testing.T) {
+	tcs := []struct {
+		name string
+		json string
+	}{{
+		name: "unsorted",
+		json: `{"compression":1,"count":2,"sum":3,"centroids":[{"mean":2,"count":1},{"mean":1,"count":1}]}`,
+	}, {
+		name: "zero count",
+		json: `{"compression":1,"count":1,"centroids":[{"mean":2,"count":1},{"mean":3,"count":0}]}`,
+	}, {
+		name: "miscounted",
+		json: `{"compression":1,"count":3,"centroids":[{"mean":1,"count":1},{"mean":2,"count":1}]}`,
+	}, {
+		name: "malformed",
+		json: `{"compression":1,`,
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := json.Unmarshal([]byte(tc.json), tdigest.New(tdigest.WithCompression(1))); err == nil {
+				t.Error("got no error")
+			}
+		})
+	}
+}
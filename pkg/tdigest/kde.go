@@ -0,0 +1,65 @@
+package tdigest
+
+import (
+	"fmt"
+	"math"
+)
+
+// KDE returns the kernel density estimate at x with a Gaussian kernel of the
+// given bandwidth, or ErrEmptyDigest if the TDigest is empty. It returns NaN
+// and an error unless bandwidth is positive.
+//
+// Each centroid contributes a Gaussian with its mean and the bandwidth as
+// standard deviation, weighted by its count. Unlike PDF, the estimate is
+// smooth, but it spreads past Min and Max. OptimalBandwidth is a reasonable
+// default bandwidth for roughly normal distributions.
+func (d *TDigest) KDE(x, bandwidth float64) (float64, error) {
+	if !(bandwidth > 0) {
+		return math.NaN(), fmt.Errorf("tdigest: KDE needs a positive bandwidth, got %v", bandwidth)
+	}
+	if d.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+	return kde(d.centroids, d.count, bandwidth, x), nil
+}
+
+// KDEFunc returns KDE with the given bandwidth as a function of x, or
+// ErrEmptyDigest if the TDigest is empty. It returns an error unless
+// bandwidth is positive.
+//
+// The function uses a copy of the centroids, so it is unaffected by values
+// added to the TDigest afterwards and is safe to call concurrently.
+func (d *TDigest) KDEFunc(bandwidth float64) (func(x float64) float64, error) {
+	if !(bandwidth > 0) {
+		return nil, fmt.Errorf("tdigest: KDE needs a positive bandwidth, got %v", bandwidth)
+	}
+	if d.nCentroids == 0 {
+		return nil, ErrEmptyDigest
+	}
+	centroids := append([]centroid(nil), d.centroids...)
+	count := d.count
+	return func(x float64) float64 {
+		return kde(centroids, count, bandwidth, x)
+	}, nil
+}
+
+// OptimalBandwidth returns the bandwidth for KDE by Silverman's rule of thumb,
+// 1.06 * StdDev() * Count()^(-1/5), or ErrEmptyDigest if the TDigest is empty.
+// It is 0 if the TDigest has a single centroid, which KDE does not accept.
+func (d *TDigest) OptimalBandwidth() (float64, error) {
+	if d.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+	return 1.06 * math.Sqrt(d.variance()) * math.Pow(d.count, -0.2), nil
+}
+
+// kde returns the Gaussian kernel density estimate at x of centroids, whose
+// counts sum to count.
+func kde(centroids []centroid, count, bandwidth, x float64) float64 {
+	var sum float64
+	for _, c := range centroids {
+		z := (x - c.mean) / bandwidth
+		sum += c.count * math.Exp(-z*z/2)
+	}
+	return sum / (count * bandwidth * math.Sqrt(2*math.Pi))
+}
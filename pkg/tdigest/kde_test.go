@@ -0,0 +1,81 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_KDE(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.New(tdigest.WithCompression(20))
+	for i := 0; i < 100000; i++ {
+		digest.Add(r.NormFloat64())
+	}
+
+	// Silverman's rule for 100000 standard normal observations.
+	bandwidth := mustFloat(digest.OptimalBandwidth())
+	if want := 1.06 * math.Pow(100000, -0.2); math.Abs(bandwidth-want) > 0.02*want {
+		t.Errorf("got OptimalBandwidth() = %v, want %v", bandwidth, want)
+	}
+
+	f, err := digest.KDEFunc(bandwidth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The density integrates to 1, by the midpoint rule over +/- 10 standard
+	// deviations.
+	const step = 0.01
+	var integral float64
+	for x := -10 + step/2; x < 10; x += step {
+		integral += f(x) * step
+	}
+	if math.Abs(integral-1) > 1e-3 {
+		t.Errorf("got integral of KDE %v, want 1", integral)
+	}
+
+	for _, x := range []float64{-2, -1, 0, 1, 2} {
+		got := mustFloat(digest.KDE(x, bandwidth))
+		if got != f(x) {
+			t.Errorf("got KDE(%v) = %v, want KDEFunc %v", x, got, f(x))
+		}
+		if want := math.Exp(-x*x/2) / math.Sqrt(2*math.Pi); math.Abs(got-want) > 0.05*want {
+			t.Errorf("got KDE(%v) = %v, want %v +/- 5%%", x, got, want)
+		}
+	}
+
+	// The function is unaffected by later values.
+	before := f(5)
+	for i := 0; i < 1000; i++ {
+		digest.Add(5)
+	}
+	if got := f(5); got != before {
+		t.Errorf("got KDEFunc(5) = %v after adding values, want %v", got, before)
+	}
+}
+
+func TestTDigest_KDE_Errors(t *testing.T) {
+	digest := newUniformRange(20, 1000, 0, 1, 1)
+	for _, bandwidth := range []float64{0, -1, math.NaN()} {
+		if got, err := digest.KDE(0.5, bandwidth); err == nil || !math.IsNaN(got) {
+			t.Errorf("got KDE(0.5, %v) = %v, %v, want NaN and an error", bandwidth, got, err)
+		}
+		if _, err := digest.KDEFunc(bandwidth); err == nil {
+			t.Errorf("got no error from KDEFunc(%v)", bandwidth)
+		}
+	}
+
+	empty := tdigest.New()
+	if got, err := empty.KDE(0.5, 1); err != tdigest.ErrEmptyDigest || !math.IsNaN(got) {
+		t.Errorf("got KDE(0.5, 1) = %v, %v on empty TDigest, want NaN, %v", got, err, tdigest.ErrEmptyDigest)
+	}
+	if _, err := empty.KDEFunc(1); err != tdigest.ErrEmptyDigest {
+		t.Errorf("got error %v from KDEFunc on empty TDigest, want %v", err, tdigest.ErrEmptyDigest)
+	}
+	if got, err := empty.OptimalBandwidth(); err != tdigest.ErrEmptyDigest || !math.IsNaN(got) {
+		t.Errorf("got OptimalBandwidth() = %v, %v on empty TDigest, want NaN, %v", got, err, tdigest.ErrEmptyDigest)
+	}
+}
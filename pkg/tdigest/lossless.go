@@ -0,0 +1,20 @@
+package tdigest
+
+// LosslessTailCount returns the number of exact single-observation centroids
+// stored in the lossless tails set by WithLosslessExtremes.
+func (d *TDigest) LosslessTailCount() int {
+	if d.losslessFraction == 0 {
+		return 0
+	}
+
+	n := 0
+	var total float64
+	for _, c := range d.centroids {
+		ptile := (total + c.count/2) / d.count
+		if c.count == 1 && (ptile < d.losslessFraction || ptile > 1-d.losslessFraction) {
+			n++
+		}
+		total += c.count
+	}
+	return n
+}
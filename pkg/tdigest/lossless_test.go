@@ -0,0 +1,42 @@
+package tdigest_test
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_WithLosslessExtremes(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.New(tdigest.WithCompression(100), tdigest.WithLosslessExtremes(0.01))
+	vals := make([]float64, 100000)
+	for i := range vals {
+		vals[i] = r.NormFloat64()
+		digest.Add(vals[i])
+	}
+	sort.Float64s(vals)
+
+	for _, q := range []float64{0.001, 0.999} {
+		// The estimate should be between the two observations whose ranks
+		// surround the quantile.
+		rank := int(q * float64(len(vals)-1))
+		got := mustFloat(digest.Quantile(q))
+		if got < vals[rank] || got > vals[rank+1] {
+			t.Errorf("got Quantile(%v) = %v, want between %v and %v", q, got, vals[rank], vals[rank+1])
+		}
+	}
+
+	// At most 1% of observations at each end are kept exactly.
+	if got := digest.LosslessTailCount(); got == 0 || got > 2000 {
+		t.Errorf("got LosslessTailCount() = %v, want between 1 and 2000", got)
+	}
+}
+
+func TestTDigest_LosslessTailCount_Disabled(t *testing.T) {
+	digest := newUniformRange(100, 10000, 0, 1, 1)
+	if got := digest.LosslessTailCount(); got != 0 {
+		t.Errorf("got LosslessTailCount() = %v, want 0", got)
+	}
+}
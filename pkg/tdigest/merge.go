@@ -0,0 +1,22 @@
+package tdigest
+
+// MergeAll returns a new TDigest with the given compression, combining the
+// observations summarized by digests, for example one per shard. Nil and empty
+// digests are skipped, and digests are not modified. It panics if compression
+// is not positive and finite.
+//
+// Rather than merging the digests one at a time, the centroids of all of them
+// are sorted together once, then merged in a single pass as by Recompress.
+// Merged centroids are within the limits Add gives them, so the result is
+// about as accurate as merging the digests with Merge at the same compression.
+// The minimum and maximum are exact. This is Build of a Builder which merged
+// each of digests.
+func MergeAll(digests []*TDigest, compression float64) *TDigest {
+	b := NewBuilder(compression)
+	for _, d := range digests {
+		b.Merge(d)
+	}
+	// Merging digests never adds invalid values.
+	result, _ := b.Build()
+	return result
+}
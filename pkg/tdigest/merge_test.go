@@ -0,0 +1,276 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/quick"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_Merge(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	vals := make([]float64, 20000)
+	a, b := tdigest.New(tdigest.WithCompression(20)), tdigest.New(tdigest.WithCompression(20))
+	for i := range vals {
+		vals[i] = r.NormFloat64()
+		if i%2 == 0 {
+			a.Add(vals[i])
+		} else {
+			b.Add(vals[i])
+		}
+	}
+	sort.Float64s(vals)
+
+	a.Merge(b)
+
+	if got := a.Count(); got != 20000 {
+		t.Errorf("got count %v, want %v", got, 20000)
+	}
+	if got := b.Count(); got != 10000 {
+		t.Errorf("got count %v for merged digest, want %v", got, 10000)
+	}
+
+	tolerance := 0.01 * (vals[len(vals)-1] - vals[0])
+	for _, q := range []float64{0.01, 0.1, 0.5, 0.9, 0.99} {
+		want := vals[int(q*float64(len(vals)-1))]
+		if got := mustFloat(a.Quantile(q)); math.Abs(got-want) > tolerance {
+			t.Errorf("got Quantile(%v) = %v, want %v +/- %v", q, got, want, tolerance)
+		}
+	}
+}
+
+func TestTDigest_Merge_Empty(t *testing.T) {
+	empty := tdigest.New(tdigest.WithCompression(20))
+	empty.Merge(tdigest.New(tdigest.WithCompression(20)))
+	if _, err := empty.Quantile(0.5); err != tdigest.ErrEmptyDigest {
+		t.Errorf("got error %v for merged empty digests, want %v", err, tdigest.ErrEmptyDigest)
+	}
+
+	d := newUniformRange(20, 1000, 0, 1, 1)
+	want := d.String()
+	d.Merge(tdigest.New(tdigest.WithCompression(20)))
+	if got := d.String(); got != want {
+		t.Errorf("got %v after merging empty digest, want %v", got, want)
+	}
+
+	empty = tdigest.New(tdigest.WithCompression(20))
+	empty.Merge(d)
+	if got := empty.Count(); got != 1000 {
+		t.Errorf("got count %v merging into empty digest, want %v", got, 1000)
+	}
+}
+
+func TestTDigest_Merge_DifferentCompression(t *testing.T) {
+	d := newUniformRange(20, 10000, 0, 1, 1)
+	d.Merge(newUniformRange(5, 10000, 0, 1, 2))
+
+	if got := d.Count(); got != 20000 {
+		t.Errorf("got count %v, want %v", got, 20000)
+	}
+	for _, q := range []float64{0.1, 0.5, 0.9} {
+		if got := mustFloat(d.Quantile(q)); math.Abs(got-q) > 0.02 {
+			t.Errorf("got Quantile(%v) = %v, want %v +/- 0.02", q, got, q)
+		}
+	}
+}
+
+// mergeInput is three random samples for checking properties of Merge with
+// testing/quick. Each sample has its own distribution, location and scale.
+type mergeInput struct {
+	a, b, c []float64
+}
+
+func (mergeInput) Generate(r *rand.Rand, _ int) reflect.Value {
+	sample := func() []float64 {
+		distributions := []func() float64{r.Float64, r.NormFloat64, r.ExpFloat64}
+		dist := distributions[r.Intn(len(distributions))]
+		loc, scale := 100*r.NormFloat64(), math.Exp(4*r.NormFloat64())
+
+		vals := make([]float64, 1000+r.Intn(4000))
+		for i := range vals {
+			vals[i] = loc + scale*dist()
+		}
+		return vals
+	}
+	return reflect.ValueOf(mergeInput{a: sample(), b: sample(), c: sample()})
+}
+
+// digests returns a digest of each sample, and all of the values sorted.
+func (in mergeInput) digests() (a, b, c *tdigest.TDigest, vals []float64) {
+	digest := func(sample []float64) *tdigest.TDigest {
+		// Keep enough centroids that each spans well under 1% of the
+		// observations.
+		d := tdigest.New(tdigest.WithCompression(0.03))
+		for _, val := range sample {
+			d.Add(val)
+		}
+		vals = append(vals, sample...)
+		return d
+	}
+	a, b, c = digest(in.a), digest(in.b), digest(in.c)
+	sort.Float64s(vals)
+	return a, b, c, vals
+}
+
+// merged returns the result of merging each of others into a copy of d.
+func merged(d *tdigest.TDigest, others ...*tdigest.TDigest) *tdigest.TDigest {
+	result := d.Clone()
+	for _, other := range others {
+		result.Merge(other)
+	}
+	return result
+}
+
+// sameQuantiles reports whether x and y estimate p50, p90 and p99 within 1% of
+// each other, either by value or by rank in vals, the sorted observations.
+// Values alone are too strict where the samples leave a gap, since any value
+// in the gap has about the same rank. Ranks alone are too strict where a
+// narrow sample piles many observations onto almost the same value.
+func sameQuantiles(t *testing.T, x, y *tdigest.TDigest, vals []float64) bool {
+	t.Helper()
+	rank := func(val float64) float64 {
+		return float64(sort.SearchFloat64s(vals, val)) / float64(len(vals))
+	}
+	for _, q := range []float64{0.5, 0.9, 0.99} {
+		gotX, gotY := mustFloat(x.Quantile(q)), mustFloat(y.Quantile(q))
+		if math.Abs(gotX-gotY) <= 0.01*math.Max(math.Abs(gotX), math.Abs(gotY)) {
+			continue
+		}
+		if math.Abs(rank(gotX)-rank(gotY)) > 0.01 {
+			t.Logf("got Quantile(%v) = %v and %v with ranks %v and %v, want within 1%%", q, gotX, gotY, rank(gotX), rank(gotY))
+			return false
+		}
+	}
+	return true
+}
+
+// mergeConfig keeps each run of the Merge properties fast. Run them with
+// -count to check more inputs.
+var mergeConfig = &quick.Config{MaxCount: 25}
+
+func TestTDigest_Merge_Commutative(t *testing.T) {
+	f := func(in mergeInput) bool {
+		a, b, _, vals := mergeInput{a: in.a, b: in.b}.digests()
+		ab, ba := merged(a, b), merged(b, a)
+		if want := float64(len(in.a) + len(in.b)); ab.Count() != want || ba.Count() != want {
+			t.Logf("got counts %v and %v, want %v", ab.Count(), ba.Count(), want)
+			return false
+		}
+		return sameQuantiles(t, ab, ba, vals)
+	}
+	if err := quick.Check(f, mergeConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTDigest_Merge_Associative(t *testing.T) {
+	f := func(in mergeInput) bool {
+		a, b, c, vals := in.digests()
+		left := merged(merged(a, b), c)
+		right := merged(a, merged(b, c))
+		if want := float64(len(vals)); left.Count() != want || right.Count() != want {
+			t.Logf("got counts %v and %v, want %v", left.Count(), right.Count(), want)
+			return false
+		}
+		return sameQuantiles(t, left, right, vals)
+	}
+	if err := quick.Check(f, mergeConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+// newShards returns n digests of size values each, drawn from one normal
+// distribution, and all of the values sorted.
+func newShards(n, size int) ([]*tdigest.TDigest, []float64) {
+	r := rand.New(rand.NewSource(1))
+	shards := make([]*tdigest.TDigest, n)
+	var vals []float64
+	for i := range shards {
+		shards[i] = tdigest.New(tdigest.WithCompression(20))
+		for j := 0; j < size; j++ {
+			val := r.NormFloat64()
+			shards[i].Add(val)
+			vals = append(vals, val)
+		}
+	}
+	sort.Float64s(vals)
+	return shards, vals
+}
+
+func TestMergeAll(t *testing.T) {
+	shards, vals := newShards(10, 10000)
+	// Nil and empty digests are skipped.
+	shards = append(shards, nil, tdigest.New())
+
+	merged := tdigest.MergeAll(shards, 20)
+
+	if err := merged.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := merged.LoadFactor(); got > 1 {
+		t.Errorf("got LoadFactor() = %v, want at most 1", got)
+	}
+	if got := merged.Count(); got != float64(len(vals)) {
+		t.Errorf("got Count() = %v, want %v", got, len(vals))
+	}
+	if got := mustFloat(merged.Min()); got != vals[0] {
+		t.Errorf("got Min() = %v, want %v", got, vals[0])
+	}
+	if got := mustFloat(merged.Max()); got != vals[len(vals)-1] {
+		t.Errorf("got Max() = %v, want %v", got, vals[len(vals)-1])
+	}
+
+	// MergeAll uses the limits Add does, so it is about as accurate as Merge
+	// at the same compression.
+	for _, compression := range []float64{10, 20} {
+		merged := tdigest.MergeAll(shards, compression)
+		sequential := tdigest.New(tdigest.WithCompression(compression))
+		for _, shard := range shards[:10] {
+			sequential.Merge(shard)
+		}
+		for _, q := range []float64{0.001, 0.01, 0.1, 0.5, 0.9, 0.99, 0.999} {
+			got := rankError(vals, q, mustFloat(merged.Quantile(q)))
+			want := rankError(vals, q, mustFloat(sequential.Quantile(q)))
+			if got > 2*want+0.0005 {
+				t.Errorf("got Quantile(%v) rank error %v at compression %v, want about %v as with Merge",
+					q, got, compression, want)
+			}
+		}
+	}
+
+	// The shards are unchanged.
+	if got := shards[0].Count(); got != 10000 {
+		t.Errorf("got shard Count() = %v, want 10000", got)
+	}
+}
+
+func TestMergeAll_Empty(t *testing.T) {
+	for _, digests := range [][]*tdigest.TDigest{nil, {nil, tdigest.New()}} {
+		merged := tdigest.MergeAll(digests, 20)
+		if _, err := merged.Quantile(0.5); err != tdigest.ErrEmptyDigest {
+			t.Errorf("got error %v merging %v, want %v", err, digests, tdigest.ErrEmptyDigest)
+		}
+	}
+}
+
+func BenchmarkMergeAll(b *testing.B) {
+	shards, _ := newShards(100, 100000)
+
+	b.Run("MergeAll", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = tdigest.MergeAll(shards, 20)
+		}
+	})
+	b.Run("Merge", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			merged := tdigest.New(tdigest.WithCompression(20))
+			for _, shard := range shards {
+				merged.Merge(shard)
+			}
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package tdigest
+
+import (
+	"fmt"
+	"math"
+)
+
+// Option configures a TDigest created by New.
+type Option func(*options)
+
+// options are the settings collected from the Options passed to New. Each set
+// field records whether its setting was given, so conflicting Options can be
+// detected.
+type options struct {
+	compression    float64
+	setCompression bool
+
+	capacity    int
+	setCapacity bool
+
+	scaleFunc ScaleFunc
+
+	losslessFraction    float64
+	setLosslessFraction bool
+}
+
+// WithCompression sets the compression of the TDigest. Higher compressions let
+// each centroid absorb more observations, so the TDigest uses fewer centroids
+// but is less accurate. It panics if compression is not positive and finite.
+//
+// Without WithCompression, New uses DefaultCompression.
+func WithCompression(compression float64) Option {
+	if !(compression > 0) || math.IsInf(compression, 1) {
+		panic(fmt.Sprintf("tdigest: compression must be positive and finite, got %v", compression))
+	}
+	return func(o *options) {
+		if o.setCompression && o.compression != compression {
+			panic(fmt.Sprintf("tdigest: conflicting compressions %v and %v", o.compression, compression))
+		}
+		o.compression = compression
+		o.setCompression = true
+	}
+}
+
+// WithInitialCapacity preallocates room for n centroids, avoiding regrowth
+// while the TDigest fills. It panics if n is negative.
+func WithInitialCapacity(n int) Option {
+	if n < 0 {
+		panic(fmt.Sprintf("tdigest: initial capacity must not be negative, got %d", n))
+	}
+	return func(o *options) {
+		if o.setCapacity && o.capacity != n {
+			panic(fmt.Sprintf("tdigest: conflicting initial capacities %d and %d", o.capacity, n))
+		}
+		o.capacity = n
+		o.setCapacity = true
+	}
+}
+
+// WithScaleFunc sets the ScaleFunc which limits the size of centroids. Without
+// WithScaleFunc, New uses ScaleFuncK2. It panics if sf is nil, or if
+// WithScaleFunc is given more than once, since ScaleFuncs can't be compared.
+//
+// The ScaleFunc isn't encoded by MarshalBinary and the other encodings.
+// Decoded TDigests use ScaleFuncK2.
+func WithScaleFunc(sf ScaleFunc) Option {
+	if sf == nil {
+		panic("tdigest: scale function must not be nil")
+	}
+	return func(o *options) {
+		if o.scaleFunc != nil {
+			panic("tdigest: conflicting scale functions")
+		}
+		o.scaleFunc = sf
+	}
+}
+
+// WithLosslessExtremes makes the TDigest keep the lowest and highest fraction
+// pct of observations as exact single-observation centroids, while the rest of
+// the distribution is compressed as usual. It panics if pct is not in [0, 0.5).
+//
+// This makes estimates of extreme quantiles exact at the cost of roughly
+// 2*pct*n centroids for n observations. Observations added while the TDigest
+// is small may already have been merged into larger centroids, so a few
+// values near the tails may not be kept exactly.
+func WithLosslessExtremes(pct float64) Option {
+	if !(pct >= 0 && pct < 0.5) {
+		panic(fmt.Sprintf("tdigest: lossless fraction must be in [0, 0.5), got %v", pct))
+	}
+	return func(o *options) {
+		if o.setLosslessFraction && o.losslessFraction != pct {
+			panic(fmt.Sprintf("tdigest: conflicting lossless fractions %v and %v", o.losslessFraction, pct))
+		}
+		o.losslessFraction = pct
+		o.setLosslessFraction = true
+	}
+}
@@ -0,0 +1,141 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestNew_Options(t *testing.T) {
+	tcs := []struct {
+		name string
+		opts []tdigest.Option
+	}{{
+		name: "default",
+	}, {
+		name: "compression",
+		opts: []tdigest.Option{tdigest.WithCompression(tdigest.DefaultCompression)},
+	}, {
+		name: "capacity",
+		opts: []tdigest.Option{tdigest.WithInitialCapacity(1000)},
+	}, {
+		name: "repeated",
+		opts: []tdigest.Option{
+			tdigest.WithCompression(tdigest.DefaultCompression),
+			tdigest.WithInitialCapacity(1000),
+			tdigest.WithCompression(tdigest.DefaultCompression),
+		},
+	}}
+
+	// The options only change how the TDigest is allocated, so every
+	// TDigest here matches the zero TDigest.
+	var want tdigest.TDigest
+	for i := 0; i < 10000; i++ {
+		want.Add(float64(i%97) / 97)
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tdigest.New(tc.opts...)
+			for i := 0; i < 10000; i++ {
+				got.Add(float64(i%97) / 97)
+			}
+			if got.String() != want.String() {
+				t.Errorf("got centroids %v, want %v", got, &want)
+			}
+		})
+	}
+}
+
+func TestNew_Options_Panics(t *testing.T) {
+	tcs := []struct {
+		name string
+		opts func() []tdigest.Option
+	}{{
+		name: "conflicting compressions",
+		opts: func() []tdigest.Option {
+			return []tdigest.Option{tdigest.WithCompression(20), tdigest.WithCompression(100)}
+		},
+	}, {
+		name: "conflicting capacities",
+		opts: func() []tdigest.Option {
+			return []tdigest.Option{tdigest.WithInitialCapacity(10), tdigest.WithInitialCapacity(20)}
+		},
+	}, {
+		name: "zero compression",
+		opts: func() []tdigest.Option { return []tdigest.Option{tdigest.WithCompression(0)} },
+	}, {
+		name: "infinite compression",
+		opts: func() []tdigest.Option { return []tdigest.Option{tdigest.WithCompression(math.Inf(1))} },
+	}, {
+		name: "conflicting scale functions",
+		opts: func() []tdigest.Option {
+			return []tdigest.Option{tdigest.WithScaleFunc(tdigest.ScaleFuncK1), tdigest.WithScaleFunc(tdigest.ScaleFuncK1)}
+		},
+	}, {
+		name: "nil scale function",
+		opts: func() []tdigest.Option { return []tdigest.Option{tdigest.WithScaleFunc(nil)} },
+	}, {
+		name: "negative capacity",
+		opts: func() []tdigest.Option { return []tdigest.Option{tdigest.WithInitialCapacity(-1)} },
+	}, {
+		name: "conflicting lossless fractions",
+		opts: func() []tdigest.Option {
+			return []tdigest.Option{tdigest.WithLosslessExtremes(0.01), tdigest.WithLosslessExtremes(0.02)}
+		},
+	}, {
+		name: "negative lossless fraction",
+		opts: func() []tdigest.Option { return []tdigest.Option{tdigest.WithLosslessExtremes(-0.01)} },
+	}, {
+		name: "half lossless fraction",
+		opts: func() []tdigest.Option { return []tdigest.Option{tdigest.WithLosslessExtremes(0.5)} },
+	}, {
+		name: "NaN lossless fraction",
+		opts: func() []tdigest.Option { return []tdigest.Option{tdigest.WithLosslessExtremes(math.NaN())} },
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("got no panic")
+				}
+			}()
+			tdigest.New(tc.opts()...)
+		})
+	}
+}
+
+func TestWithScaleFunc(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	vals := make([]float64, 100000)
+	for i := range vals {
+		vals[i] = r.NormFloat64()
+	}
+
+	// At the same compression, k1 keeps the fewest centroids and k3 the most.
+	centroids := func(d *tdigest.TDigest) int {
+		d.AddAll(vals)
+		return d.CentroidCount()
+	}
+
+	k1 := centroids(tdigest.New(tdigest.WithScaleFunc(tdigest.ScaleFuncK1)))
+	k2 := centroids(tdigest.New(tdigest.WithScaleFunc(tdigest.ScaleFuncK2)))
+	k3 := centroids(tdigest.New(tdigest.WithScaleFunc(tdigest.ScaleFuncK3)))
+	if !(k1 < k2 && k2 < k3) {
+		t.Errorf("got CentroidCount() = %v, %v, and %v for k1, k2, and k3, want increasing", k1, k2, k3)
+	}
+	if got := centroids(tdigest.New()); got != k2 {
+		t.Errorf("got CentroidCount() = %v by default, want %v as with k2", got, k2)
+	}
+
+	// Reset keeps the scale function.
+	d := tdigest.New(tdigest.WithScaleFunc(tdigest.ScaleFuncK3))
+	d.AddAll(vals)
+	d.Reset()
+	if got := centroids(d); got != k3 {
+		t.Errorf("got CentroidCount() = %v after Reset, want %v as with k3", got, k3)
+	}
+}
@@ -0,0 +1,149 @@
+package tdigest
+
+import (
+	"fmt"
+	"math"
+)
+
+// The scales OpenTelemetry allows for exponential histograms.
+const (
+	otelMinScale = -10
+	otelMaxScale = 20
+)
+
+// otelMaxBuckets is the most buckets ToOTelExponentialHistogram returns for
+// each sign, the default maximum size of OpenTelemetry SDK exponential
+// histograms.
+const otelMaxBuckets = 160
+
+// OTelBuckets are the buckets of one sign of an OpenTelemetry exponential
+// histogram, as in ExponentialHistogramDataPoint.Buckets. BucketCounts[i]
+// counts observations in the bucket with index Offset+i.
+type OTelBuckets struct {
+	Offset       int32
+	BucketCounts []uint64
+}
+
+// ToOTelExponentialHistogram returns the buckets of an OpenTelemetry
+// exponential histogram at the highest scale up to maxScale at which each sign
+// needs at most 160 buckets: the scale, counts of positive observations, of
+// negative observations by their absolute value, and of zeros. At scale s, the
+// bucket with index i covers (2^(i/2^s), 2^((i+1)/2^s)], so each increment of
+// the scale halves the width of the buckets. An empty TDigest has no buckets.
+//
+// ToOTelExponentialHistogram panics unless maxScale is between -10 and 20, the
+// scales OpenTelemetry allows.
+//
+// Each centroid's observations are counted in the bucket of its mean, so
+// buckets narrower than the centroids are uneven. Counts are rounded so that
+// they sum to Count rounded to an integer.
+//
+// The buckets span every index between the smallest and largest means, so at
+// high scales widely spread observations would need many buckets. As
+// OpenTelemetry SDKs do, the scale is lowered until they fit, so the buckets
+// use memory independent of the range of observations.
+func (d *TDigest) ToOTelExponentialHistogram(maxScale int) (scale int, positive, negative OTelBuckets, zeroCount uint64) {
+	if maxScale < otelMinScale || maxScale > otelMaxScale {
+		panic(fmt.Sprintf("tdigest: OpenTelemetry scale must be between %d and %d, got %d", otelMinScale, otelMaxScale, maxScale))
+	}
+	scale = d.otelScale(maxScale)
+
+	// Round the running total rather than each centroid's count, so the
+	// rounding errors don't add up.
+	var total float64
+	var rounded uint64
+	next := func(count float64) uint64 {
+		total += count
+		n := uint64(math.Round(total)) - rounded
+		rounded += n
+		return n
+	}
+
+	for _, c := range d.centroids {
+		n := next(c.count)
+		switch {
+		case c.mean > 0:
+			positive.add(otelIndex(c.mean, scale), n)
+		case c.mean < 0:
+			negative.add(otelIndex(-c.mean, scale), n)
+		default:
+			zeroCount += n
+		}
+	}
+	return scale, positive, negative, zeroCount
+}
+
+// otelScale returns the highest scale up to maxScale at which the means of
+// each sign span at most otelMaxBuckets buckets. At the lowest scale, every
+// float64 fits in a few buckets.
+func (d *TDigest) otelScale(maxScale int) int {
+	// The smallest and largest absolute values of the positive and negative
+	// means. Centroids are sorted by mean, so the last negative mean is the
+	// closest to zero.
+	var posLo, posHi, negLo, negHi float64
+	for _, c := range d.centroids {
+		switch {
+		case c.mean > 0:
+			if posLo == 0 {
+				posLo = c.mean
+			}
+			posHi = c.mean
+		case c.mean < 0:
+			if negHi == 0 {
+				negHi = -c.mean
+			}
+			negLo = -c.mean
+		}
+	}
+
+	fits := func(lo, hi float64, scale int) bool {
+		// The difference of indices of the smallest and largest float64s can
+		// overflow an int32.
+		return lo == 0 || int64(otelIndex(hi, scale))-int64(otelIndex(lo, scale)) < otelMaxBuckets
+	}
+	scale := maxScale
+	for scale > otelMinScale && !(fits(posLo, posHi, scale) && fits(negLo, negHi, scale)) {
+		scale--
+	}
+	return scale
+}
+
+// add adds n observations to the bucket with index idx, extending the buckets
+// as needed.
+func (b *OTelBuckets) add(idx int32, n uint64) {
+	if len(b.BucketCounts) == 0 {
+		b.Offset = idx
+	}
+	if idx < b.Offset {
+		grown := make([]uint64, int(b.Offset-idx)+len(b.BucketCounts))
+		copy(grown[b.Offset-idx:], b.BucketCounts)
+		b.BucketCounts, b.Offset = grown, idx
+	}
+	for int(idx-b.Offset) >= len(b.BucketCounts) {
+		b.BucketCounts = append(b.BucketCounts, 0)
+	}
+	b.BucketCounts[idx-b.Offset] += n
+}
+
+// otelIndex returns the index of the bucket containing val, which must be
+// positive, at the given scale.
+//
+// As the OpenTelemetry specification recommends, exact powers of two are found
+// from the exponent rather than the logarithm, which may round them into the
+// bucket above.
+func otelIndex(val float64, scale int) int32 {
+	frac, exp := math.Frexp(val)
+	// val is in [2^(exp-1), 2^exp). If frac is 0.5, val is exactly 2^(exp-1).
+	if scale <= 0 {
+		if frac == 0.5 {
+			// val is the upper boundary of the bucket below.
+			exp--
+		}
+		// Each bucket spans 2^-scale powers of two.
+		return int32((exp - 1) >> -scale)
+	}
+	if frac == 0.5 {
+		return int32((exp-1)<<scale) - 1
+	}
+	return int32(math.Ceil(math.Log2(val)*math.Ldexp(1, scale))) - 1
+}
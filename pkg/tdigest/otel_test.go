@@ -0,0 +1,150 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_ToOTelExponentialHistogram(t *testing.T) {
+	digest, err := tdigest.FromCentroids(20, []tdigest.CentroidData{
+		{Mean: -3, Count: 2},
+		{Mean: 0, Count: 4},
+		{Mean: 1, Count: 1},
+		{Mean: 2, Count: 1},
+		{Mean: 3, Count: 5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tcs := []struct {
+		name               string
+		scale              int
+		positive, negative tdigest.OTelBuckets
+	}{{
+		// Buckets are (1/4, 1], (1, 4], (4, 16].
+		name:     "negative scale",
+		scale:    -1,
+		positive: tdigest.OTelBuckets{Offset: -1, BucketCounts: []uint64{1, 6}},
+		negative: tdigest.OTelBuckets{Offset: 0, BucketCounts: []uint64{2}},
+	}, {
+		// Buckets are (1/2, 1], (1, 2], (2, 4].
+		name:     "zero scale",
+		scale:    0,
+		positive: tdigest.OTelBuckets{Offset: -1, BucketCounts: []uint64{1, 1, 5}},
+		negative: tdigest.OTelBuckets{Offset: 1, BucketCounts: []uint64{2}},
+	}, {
+		// Buckets are (2^(-1/2), 1], (1, 2^(1/2)], (2^(1/2), 2], ...
+		name:     "positive scale",
+		scale:    1,
+		positive: tdigest.OTelBuckets{Offset: -1, BucketCounts: []uint64{1, 0, 1, 0, 5}},
+		negative: tdigest.OTelBuckets{Offset: 3, BucketCounts: []uint64{2}},
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			scale, positive, negative, zeroCount := digest.ToOTelExponentialHistogram(tc.scale)
+			if scale != tc.scale {
+				t.Errorf("got scale %d, want %d", scale, tc.scale)
+			}
+			if !reflect.DeepEqual(positive, tc.positive) {
+				t.Errorf("got positive buckets %+v, want %+v", positive, tc.positive)
+			}
+			if !reflect.DeepEqual(negative, tc.negative) {
+				t.Errorf("got negative buckets %+v, want %+v", negative, tc.negative)
+			}
+			if zeroCount != 4 {
+				t.Errorf("got zero count %v, want 4", zeroCount)
+			}
+		})
+	}
+}
+
+func TestTDigest_ToOTelExponentialHistogram_LogNormal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.New(tdigest.WithCompression(20))
+	for i := 0; i < 100000; i++ {
+		digest.Add(math.Exp(r.NormFloat64()))
+	}
+
+	for _, scale := range []int{-10, -2, 0, 3, 8} {
+		_, positive, negative, zeroCount := digest.ToOTelExponentialHistogram(scale)
+		var sum uint64
+		for _, n := range positive.BucketCounts {
+			sum += n
+		}
+		if len(negative.BucketCounts) != 0 || zeroCount != 0 {
+			t.Errorf("got negative buckets %+v and zero count %v at scale %d, want none", negative, zeroCount, scale)
+		}
+		if sum != uint64(digest.Count()) {
+			t.Errorf("got bucket counts summing to %v at scale %d, want %v", sum, scale, digest.Count())
+		}
+	}
+
+	// Half of the observations are below 1, in buckets with negative indices.
+	_, positive, _, _ := digest.ToOTelExponentialHistogram(0)
+	var below uint64
+	for i, n := range positive.BucketCounts {
+		if int(positive.Offset)+i < 0 {
+			below += n
+		}
+	}
+	if got := float64(below) / digest.Count(); math.Abs(got-0.5) > 0.02 {
+		t.Errorf("got fraction %v of observations at most 1, want 0.5 +/- 0.02", got)
+	}
+}
+
+func TestTDigest_ToOTelExponentialHistogram_MaxBuckets(t *testing.T) {
+	digest, err := tdigest.FromCentroids(20, []tdigest.CentroidData{
+		{Mean: -1e300, Count: 1},
+		{Mean: -1e-300, Count: 1},
+		{Mean: 1e-300, Count: 1},
+		{Mean: 1, Count: 1},
+		{Mean: 1e300, Count: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// At scale 20, these would need billions of buckets, so the scale is
+	// lowered until each sign needs at most 160.
+	scale, positive, negative, _ := digest.ToOTelExponentialHistogram(20)
+	if scale >= 20 {
+		t.Errorf("got scale %d, want less than 20", scale)
+	}
+	for _, b := range []tdigest.OTelBuckets{positive, negative} {
+		if len(b.BucketCounts) > 160 {
+			t.Errorf("got %d buckets at scale %d, want at most 160", len(b.BucketCounts), scale)
+		}
+	}
+
+	// The buckets don't fit at the next scale up, so it is lowered to the
+	// same scale.
+	if got, _, _, _ := digest.ToOTelExponentialHistogram(scale + 1); got != scale {
+		t.Errorf("got scale %d with maximum scale %d, want %d", got, scale+1, scale)
+	}
+}
+
+func TestTDigest_ToOTelExponentialHistogram_Empty(t *testing.T) {
+	_, positive, negative, zeroCount := tdigest.New().ToOTelExponentialHistogram(0)
+	if len(positive.BucketCounts) != 0 || len(negative.BucketCounts) != 0 || zeroCount != 0 {
+		t.Errorf("got %+v, %+v, %v for empty digest, want no buckets", positive, negative, zeroCount)
+	}
+}
+
+func TestTDigest_ToOTelExponentialHistogram_Panics(t *testing.T) {
+	for _, scale := range []int{-11, 21} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("got no panic for scale %d", scale)
+				}
+			}()
+			newUniformRange(20, 100, 1, 2, 1).ToOTelExponentialHistogram(scale)
+		}()
+	}
+}
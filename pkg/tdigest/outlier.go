@@ -0,0 +1,100 @@
+package tdigest
+
+import (
+	"fmt"
+	"math"
+)
+
+// outlierWarmup is the number of multiples of the compression which are always
+// added to the main digest before any values are considered outliers.
+//
+// The variance estimated from centroids is far too small until there are
+// enough centroids to describe the spread of the distribution. Without a
+// warmup, nearly every value looks like an outlier and the main digest never
+// grows enough to correct itself.
+const outlierWarmup = 5
+
+// OutlierDigest separates outliers from the main distribution, keeping each in
+// its own TDigest.
+//
+// Values further than sigmaThreshold standard deviations from the mean of the
+// main digest are considered outliers. Since the mean and standard deviation
+// are estimated from the main digest's centroids, the threshold is approximate.
+type OutlierDigest struct {
+	main     *TDigest
+	outliers *TDigest
+
+	sigmaThreshold float64
+}
+
+func NewOutlierDigest(compression float64, sigmaThreshold float64) *OutlierDigest {
+	return &OutlierDigest{
+		main:           New(WithCompression(compression)),
+		outliers:       New(WithCompression(compression)),
+		sigmaThreshold: sigmaThreshold,
+	}
+}
+
+// Add adds val to either the main or the outlier digest. It returns
+// ErrInvalidValue if val is NaN or infinite.
+func (d *OutlierDigest) Add(val float64) error {
+	if d.main.count < outlierWarmup*d.main.compression {
+		return d.main.Add(val)
+	}
+
+	stdDev := math.Sqrt(d.main.variance())
+	if math.Abs(val-d.main.mean()) > d.sigmaThreshold*stdDev {
+		return d.outliers.Add(val)
+	}
+	return d.main.Add(val)
+}
+
+// MainDigest returns the digest of values which are not outliers.
+func (d *OutlierDigest) MainDigest() *TDigest {
+	return d.main
+}
+
+// OutlierDigest returns the digest of outliers.
+func (d *OutlierDigest) OutlierDigest() *TDigest {
+	return d.outliers
+}
+
+// OutlierFraction returns the fraction of all added values which were
+// considered outliers.
+func (d *OutlierDigest) OutlierFraction() float64 {
+	total := d.main.count + d.outliers.count
+	if total == 0 {
+		return 0
+	}
+	return d.outliers.count / total
+}
+
+// OutlierScore returns how extreme x is relative to the observations, as the
+// distance of CDF(x) from the median, |CDF(x) - 0.5|. Scores range from 0 for
+// x at the median to 0.5 for x at or beyond Min or Max. It returns
+// ErrEmptyDigest if the TDigest is empty.
+func (d *TDigest) OutlierScore(x float64) (float64, error) {
+	if d.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+	return math.Abs(d.cdf(x) - 0.5), nil
+}
+
+// IsTukeysOutlier returns whether x is an outlier by Tukey's rule: below
+// Q1 - k*IQR or above Q3 + k*IQR, where Q1 and Q3 are the estimated first and
+// third quartiles. Conventionally k is 1.5 for mild outliers and 3 for extreme
+// ones.
+//
+// It returns false and ErrEmptyDigest if the TDigest is empty, and an error if
+// k is negative or NaN.
+func (d *TDigest) IsTukeysOutlier(x, k float64) (bool, error) {
+	if !(k >= 0) {
+		return false, fmt.Errorf("tdigest: Tukey's rule needs k >= 0, got %v", k)
+	}
+	if d.nCentroids == 0 {
+		return false, ErrEmptyDigest
+	}
+	q1, q3 := d.quantile(0.25), d.quantile(0.75)
+	iqr := q3 - q1
+	return x < q1-k*iqr || x > q3+k*iqr, nil
+}
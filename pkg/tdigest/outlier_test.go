@@ -0,0 +1,127 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestOutlierDigest(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.NewOutlierDigest(100, 4)
+
+	for i := 0; i < 100000; i++ {
+		val := r.NormFloat64()
+		if r.Float64() < 0.01 {
+			// Contaminate 1% of values with outliers between 10 and 20 standard
+			// deviations from the mean.
+			val = 10 + 10*r.Float64()
+			if r.Intn(2) == 0 {
+				val = -val
+			}
+		}
+		digest.Add(val)
+	}
+
+	if got := digest.OutlierFraction(); math.Abs(got-0.01) > 0.002 {
+		t.Errorf("got OutlierFraction() = %v, want approximately 0.01", got)
+	}
+
+	// The main distribution should be unaffected by the outliers.
+	main := digest.MainDigest()
+	if got := mustFloat(main.Quantile(0.999)); got > 5 {
+		t.Errorf("got main Quantile(0.999) = %v, want less than 5", got)
+	}
+	if got := mustFloat(main.Quantile(0.001)); got < -5 {
+		t.Errorf("got main Quantile(0.001) = %v, want greater than -5", got)
+	}
+
+	// The outliers were split between both tails.
+	outliers := digest.OutlierDigest()
+	if got := mustFloat(outliers.Quantile(0.01)); got > -10 {
+		t.Errorf("got outlier Quantile(0.01) = %v, want less than -10", got)
+	}
+	if got := mustFloat(outliers.Quantile(0.99)); got < 10 {
+		t.Errorf("got outlier Quantile(0.99) = %v, want greater than 10", got)
+	}
+}
+
+func TestOutlierDigest_Empty(t *testing.T) {
+	digest := tdigest.NewOutlierDigest(100, 3)
+	if got := digest.OutlierFraction(); got != 0 {
+		t.Errorf("got OutlierFraction() = %v, want 0", got)
+	}
+}
+
+func TestTDigest_OutlierScore(t *testing.T) {
+	digest := newUniformRange(20, 100000, 0, 1, 1)
+
+	tcs := []struct {
+		x    float64
+		want float64
+	}{{
+		x: 0.5, want: 0,
+	}, {
+		x: 0.9, want: 0.4,
+	}, {
+		x: 0.1, want: 0.4,
+	}, {
+		x: -1, want: 0.5,
+	}, {
+		x: 2, want: 0.5,
+	}}
+
+	for _, tc := range tcs {
+		if got := mustFloat(digest.OutlierScore(tc.x)); math.Abs(got-tc.want) > 0.01 {
+			t.Errorf("got OutlierScore(%v) = %v, want %v +/- 0.01", tc.x, got, tc.want)
+		}
+	}
+
+	if _, err := tdigest.New().OutlierScore(0); err != tdigest.ErrEmptyDigest {
+		t.Errorf("got error %v for empty digest, want %v", err, tdigest.ErrEmptyDigest)
+	}
+}
+
+func TestTDigest_IsTukeysOutlier(t *testing.T) {
+	// Q1 = 0.25 and Q3 = 0.75, so the fences for k = 1.5 are -0.5 and 1.5,
+	// and for k = 3 are -1.25 and 2.25.
+	digest := newUniformRange(20, 100000, 0, 1, 1)
+
+	tcs := []struct {
+		x, k float64
+		want bool
+	}{{
+		x: 0.5, k: 1.5, want: false,
+	}, {
+		x: 1.4, k: 1.5, want: false,
+	}, {
+		x: 1.6, k: 1.5, want: true,
+	}, {
+		x: -0.6, k: 1.5, want: true,
+	}, {
+		x: 1.6, k: 3, want: false,
+	}, {
+		x: 2.3, k: 3, want: true,
+	}, {
+		x: -1.3, k: 3, want: true,
+	}}
+
+	for _, tc := range tcs {
+		got, err := digest.IsTukeysOutlier(tc.x, tc.k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("got IsTukeysOutlier(%v, %v) = %v, want %v", tc.x, tc.k, got, tc.want)
+		}
+	}
+
+	if _, err := digest.IsTukeysOutlier(0, -1); err == nil {
+		t.Error("got no error for negative k")
+	}
+	if _, err := tdigest.New().IsTukeysOutlier(0, 1.5); err != tdigest.ErrEmptyDigest {
+		t.Errorf("got error %v for empty digest, want %v", err, tdigest.ErrEmptyDigest)
+	}
+}
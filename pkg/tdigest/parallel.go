@@ -0,0 +1,116 @@
+package tdigest
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// cacheLineSize is the size of a cache line on common architectures.
+const cacheLineSize = 64
+
+// ParallelTDigest is a TDigest which many goroutines may add to at once. It
+// splits observations between shards, each a TDigest with its own lock, so
+// concurrent adds rarely wait for each other. Queries merge the shards.
+//
+// Use it rather than SyncTDigest when adds from many goroutines contend for
+// the lock. Queries are slower, since each one merges every shard.
+type ParallelTDigest struct {
+	compression float64
+	shards      []parallelShard
+
+	// next is the shard the next add tries first.
+	next atomic.Uint64
+}
+
+// parallelShard is one shard of a ParallelTDigest, padded so neighboring
+// shards' locks don't share a cache line.
+type parallelShard struct {
+	mu     sync.Mutex
+	digest *TDigest
+	_      [cacheLineSize - unsafe.Sizeof(sync.Mutex{}) - unsafe.Sizeof((*TDigest)(nil))]byte
+}
+
+// NewParallel returns a ParallelTDigest with the given compression and number
+// of shards. If shards is not positive, there is one shard per GOMAXPROCS.
+func NewParallel(compression float64, shards int) *ParallelTDigest {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	d := &ParallelTDigest{
+		compression: compression,
+		shards:      make([]parallelShard, shards),
+	}
+	for i := range d.shards {
+		d.shards[i].digest = New(WithCompression(compression))
+	}
+	return d
+}
+
+// Add adds val to one of the shards. It returns ErrInvalidValue if val is NaN
+// or infinite.
+//
+// Shards are tried in turn, starting from the one after the previous add's, and
+// val is added to the first which isn't locked. If all of them are, Add waits
+// for the first.
+func (d *ParallelTDigest) Add(val float64) error {
+	if !isValid(val) {
+		return ErrInvalidValue
+	}
+
+	n := uint64(len(d.shards))
+	start := d.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		shard := &d.shards[(start+i)%n]
+		if shard.mu.TryLock() {
+			shard.add(val)
+			return nil
+		}
+	}
+	shard := &d.shards[start%n]
+	shard.mu.Lock()
+	shard.add(val)
+	return nil
+}
+
+// add adds val to the shard and unlocks it.
+func (s *parallelShard) add(val float64) {
+	// val is valid, so Add can't fail.
+	_ = s.digest.Add(val)
+	s.mu.Unlock()
+}
+
+// Snapshot returns a TDigest of the observations of all shards, merged as by
+// MergeAll. Each shard is locked only while it is read, so adds may continue
+// on the others.
+func (d *ParallelTDigest) Snapshot() *TDigest {
+	b := NewBuilder(d.compression)
+	for i := range d.shards {
+		shard := &d.shards[i]
+		shard.mu.Lock()
+		b.Merge(shard.digest)
+		shard.mu.Unlock()
+	}
+	// Merging digests never adds invalid values.
+	merged, _ := b.Build()
+	return merged
+}
+
+// Quantile returns the quantile q of the merged shards, or ErrEmptyDigest if
+// there are no observations.
+func (d *ParallelTDigest) Quantile(q float64) (float64, error) {
+	return d.Snapshot().Quantile(q)
+}
+
+// Count returns the number of observations in all shards.
+func (d *ParallelTDigest) Count() float64 {
+	var count float64
+	for i := range d.shards {
+		shard := &d.shards[i]
+		shard.mu.Lock()
+		count += shard.digest.count
+		shard.mu.Unlock()
+	}
+	return count
+}
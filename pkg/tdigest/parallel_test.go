@@ -0,0 +1,80 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+// TestParallelTDigest exercises concurrent writes and reads. Run with -race to
+// check for data races.
+func TestParallelTDigest(t *testing.T) {
+	const goroutines = 8
+	const perGoroutine = 10000
+
+	d := tdigest.NewParallel(20, 4)
+	if _, err := d.Quantile(0.5); err != tdigest.ErrEmptyDigest {
+		t.Errorf("got error %v for empty digest, want %v", err, tdigest.ErrEmptyDigest)
+	}
+	if err := d.Add(math.NaN()); err != tdigest.ErrInvalidValue {
+		t.Errorf("got error %v adding NaN, want %v", err, tdigest.ErrInvalidValue)
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for j := 0; j < perGoroutine; j++ {
+				d.Add(r.Float64())
+				if j%1000 == 0 {
+					_ = mustFloat(d.Quantile(0.5))
+				}
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+
+	if got := d.Count(); got != goroutines*perGoroutine {
+		t.Errorf("got Count() = %v, want %v", got, goroutines*perGoroutine)
+	}
+	for _, q := range []float64{0.01, 0.5, 0.99} {
+		if got := mustFloat(d.Quantile(q)); math.Abs(got-q) > 0.01 {
+			t.Errorf("got Quantile(%v) = %v, want %v +/- 0.01", q, got, q)
+		}
+	}
+
+	// The snapshot is independent of the shards.
+	snapshot := d.Snapshot()
+	d.Add(2)
+	if got := snapshot.Count(); got != goroutines*perGoroutine {
+		t.Errorf("got snapshot Count() = %v after adding, want %v", got, goroutines*perGoroutine)
+	}
+}
+
+// BenchmarkParallelTDigest_Add compares concurrent adds to a ParallelTDigest
+// and a SyncTDigest. Run with -cpu=1,2,4,8 to see how each scales.
+func BenchmarkParallelTDigest_Add(b *testing.B) {
+	b.Run("Parallel", func(b *testing.B) {
+		d := tdigest.NewParallel(100, 0)
+		b.RunParallel(func(pb *testing.PB) {
+			r := rand.New(rand.NewSource(rand.Int63()))
+			for pb.Next() {
+				d.Add(r.Float64())
+			}
+		})
+	})
+	b.Run("Sync", func(b *testing.B) {
+		d := tdigest.NewSync(100)
+		b.RunParallel(func(pb *testing.PB) {
+			r := rand.New(rand.NewSource(rand.Int63()))
+			for pb.Next() {
+				d.Add(r.Float64())
+			}
+		})
+	})
+}
@@ -0,0 +1,35 @@
+package tdigest
+
+import "sync"
+
+// Pool is a set of reusable TDigests with the same compression. It reduces
+// garbage collection pressure when many short-lived TDigests are created and
+// discarded, such as one per request.
+//
+// A Pool is safe for use by multiple goroutines.
+type Pool struct {
+	compression float64
+	pool        sync.Pool
+}
+
+// NewPool returns an empty Pool of TDigests with the given compression.
+func NewPool(compression float64) *Pool {
+	p := &Pool{compression: compression}
+	p.pool.New = func() interface{} {
+		return New(WithCompression(compression))
+	}
+	return p
+}
+
+// Get returns an empty TDigest from the Pool, allocating one if the Pool is
+// empty.
+func (p *Pool) Get() *TDigest {
+	return p.pool.Get().(*TDigest)
+}
+
+// Put resets d and returns it to the Pool. d must not be used afterwards.
+func (p *Pool) Put(d *TDigest) {
+	d.reset()
+	d.compression = p.compression
+	p.pool.Put(d)
+}
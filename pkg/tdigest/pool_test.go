@@ -0,0 +1,54 @@
+package tdigest
+
+import "testing"
+
+func TestPool(t *testing.T) {
+	p := NewPool(100)
+
+	d := p.Get()
+	for i := 0; i < 1000; i++ {
+		d.Add(float64(i))
+	}
+	p.Put(d)
+
+	d = p.Get()
+	if d.count != 0 || d.nCentroids != 0 || len(d.centroids) != 0 {
+		t.Errorf("got count %v with %d centroids from Get(), want empty", d.count, len(d.centroids))
+	}
+	if d.p5Centroid != 0 || d.p95Centroid != 0 || d.appendLower {
+		t.Errorf("got cached values %d, %d, %v from Get(), want zero", d.p5Centroid, d.p95Centroid, d.appendLower)
+	}
+	if d.compression != 100 {
+		t.Errorf("got compression %v from Get(), want %v", d.compression, 100)
+	}
+
+	// The digest behaves like a new one.
+	want := New(WithCompression(100))
+	for i := 0; i < 1000; i++ {
+		d.Add(float64(i))
+		want.Add(float64(i))
+	}
+	if got, want := d.String(), want.String(); got != want {
+		t.Errorf("got reused digest %v, want %v", got, want)
+	}
+}
+
+func TestPool_PutForeign(t *testing.T) {
+	p := NewPool(100)
+	p.Put(New(WithCompression(500)))
+
+	if got := p.Get().compression; got != 100 {
+		t.Errorf("got compression %v from Get(), want %v", got, 100)
+	}
+}
+
+func BenchmarkPool(b *testing.B) {
+	p := NewPool(100)
+	p.Put(p.Get())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Put(p.Get())
+	}
+}
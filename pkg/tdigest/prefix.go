@@ -0,0 +1,90 @@
+package tdigest
+
+// The prefix counts of a TDigest are a Fenwick tree over the counts of its
+// centroids: prefixCounts[i] holds the total count of the centroids from
+// i-(i&-i) to i-1. The count of the centroids before any index can be found,
+// and updated when a centroid grows, in O(log n) time.
+//
+// Inserting a centroid would change O(n) entries, so instead the tree is
+// invalidated, and rebuilt in O(n) time the next time it is needed. Many
+// centroids may be inserted between uses.
+
+// prefixMinCentroids is the fewest centroids for which the prefix counts are
+// kept. With fewer, summing the counts directly is faster than keeping the
+// tree up to date.
+const prefixMinCentroids = 64
+
+// buildPrefixCounts rebuilds the prefix counts from the centroids.
+func (d *TDigest) buildPrefixCounts() {
+	n := len(d.centroids)
+	if cap(d.prefixCounts) < n+1 {
+		// Leave room for the centroids to grow into.
+		d.prefixCounts = make([]float64, n+1, cap(d.centroids)+1)
+	}
+	t := d.prefixCounts[:n+1]
+	t[0] = 0
+	for i, c := range d.centroids {
+		t[i+1] = c.count
+	}
+	for i := 1; i <= n; i++ {
+		if j := i + i&-i; j <= n {
+			t[j] += t[i]
+		}
+	}
+	d.prefixCounts = t
+	d.prefixValid = true
+}
+
+// addPrefixCount records that the centroid at idx gained count observations.
+func (d *TDigest) addPrefixCount(idx int, count float64) {
+	if !d.prefixValid {
+		return
+	}
+	t := d.prefixCounts
+	for i := idx + 1; i < len(t); i += i & -i {
+		t[i] += count
+	}
+}
+
+// prefixCount returns the total count of the centroids before idx.
+func (d *TDigest) prefixCount(idx int) float64 {
+	var total float64
+	if d.nCentroids < prefixMinCentroids {
+		for _, c := range d.centroids[:idx] {
+			total += c.count
+		}
+		return total
+	}
+
+	if !d.prefixValid {
+		d.buildPrefixCounts()
+	}
+	for i := idx; i > 0; i -= i & -i {
+		total += d.prefixCounts[i]
+	}
+	return total
+}
+
+// inc adds val to the centroid at idx.
+func (d *TDigest) inc(idx int, val float64) {
+	d.centroids[idx].inc(val)
+	d.addPrefixCount(idx, 1)
+}
+
+// incN adds count observations of val to the centroid at idx.
+func (d *TDigest) incN(idx int, val, count float64) {
+	d.centroids[idx].incN(val, count)
+	d.addPrefixCount(idx, count)
+}
+
+// fill adds as many of count observations of val to the centroid at idx as it
+// has room for, and returns the number left over. The centroid must not have
+// room for all of them.
+func (d *TDigest) fill(idx int, val, count float64) float64 {
+	room := d.centroids[idx].maxCount - d.centroids[idx].count
+	if room <= 0 {
+		return count
+	}
+	d.incN(idx, val, room)
+	return count - room
+}
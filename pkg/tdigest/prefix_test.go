@@ -0,0 +1,74 @@
+package tdigest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// checkPrefixCounts checks the prefix count of every centroid against the sum
+// of the counts before it.
+func checkPrefixCounts(t *testing.T, d *TDigest) {
+	t.Helper()
+	var total float64
+	for i, c := range d.centroids {
+		if got := d.prefixCount(i); math.Abs(got-total) > 1e-9*d.count {
+			t.Fatalf("got prefixCount(%d) = %v, want %v", i, got, total)
+		}
+		total += c.count
+	}
+}
+
+func TestTDigest_prefixCount(t *testing.T) {
+	tcs := []struct {
+		name   string
+		digest *TDigest
+	}{{
+		name:   "unbounded",
+		digest: New(WithCompression(5)),
+	}, {
+		name:   "fixed",
+		digest: NewFixed(5, 50),
+	}, {
+		name:   "eager",
+		digest: New(WithCompression(5)),
+	}}
+	tcs[2].digest.SetEagerAdd(true)
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			d := tc.digest
+			r := rand.New(rand.NewSource(1))
+			for i := 0; i < 20; i++ {
+				// Query between batches, so later batches update a valid tree
+				// rather than rebuilding it.
+				for j := 0; j < 500; j++ {
+					d.Add(r.NormFloat64())
+				}
+				checkPrefixCounts(t, d)
+
+				d.AddWeighted(r.NormFloat64(), 1+10*r.Float64())
+				checkPrefixCounts(t, d)
+			}
+
+			d.Accumulate(newUniform(5, 1000, 2))
+			checkPrefixCounts(t, d)
+			d.Accumulate(d)
+			checkPrefixCounts(t, d)
+			d.Recompress()
+			checkPrefixCounts(t, d)
+			checkPrefixCounts(t, d.Clone())
+		})
+	}
+}
+
+func TestDecayTDigest_prefixCount(t *testing.T) {
+	d := NewDecay(5, 0.99)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		d.Add(r.Float64())
+		if i%100 == 0 {
+			checkPrefixCounts(t, d.digest)
+		}
+	}
+}
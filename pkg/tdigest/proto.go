@@ -0,0 +1,174 @@
+package tdigest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Protocol Buffer wire types and field numbers of the messages in
+// proto/tdigest.proto.
+//
+// The encoding is written by hand rather than generated by protoc, since
+// generated code needs google.golang.org/protobuf and this package has no
+// dependencies. The messages are small and only use doubles and repeated
+// messages, so the wire format is simple to read and write directly. Keep
+// these in sync with the .proto file; TestProtoFieldNumbers checks that they
+// are. Users of other languages can generate code from the .proto file.
+const (
+	protoWireVarint  = 0
+	protoWireFixed64 = 1
+	protoWireBytes   = 2
+	protoWireFixed32 = 5
+
+	protoTDigestCompression = 1
+	protoTDigestCount       = 2
+	protoTDigestCentroids   = 3
+	protoTDigestSum         = 4
+
+	protoCentroidMean  = 1
+	protoCentroidCount = 2
+)
+
+var errTruncatedProto = errors.New("tdigest: truncated protocol buffer")
+
+// MarshalProto encodes the TDigest as the TDigest message defined in
+// proto/tdigest.proto, so it can be shared with other languages.
+func (d *TDigest) MarshalProto() ([]byte, error) {
+	// Each centroid is at most a two byte header and two 9 byte fields.
+	b := make([]byte, 0, 18+20*len(d.centroids))
+	b = appendProtoDouble(b, protoTDigestCompression, d.Compression())
+	b = appendProtoDouble(b, protoTDigestCount, d.count)
+	b = appendProtoDouble(b, protoTDigestSum, d.sum)
+
+	var centroid []byte
+	for _, c := range d.centroids {
+		centroid = appendProtoDouble(centroid[:0], protoCentroidMean, c.mean)
+		centroid = appendProtoDouble(centroid, protoCentroidCount, c.count)
+		b = binary.AppendUvarint(b, protoTDigestCentroids<<3|protoWireBytes)
+		b = binary.AppendUvarint(b, uint64(len(centroid)))
+		b = append(b, centroid...)
+	}
+	return b, nil
+}
+
+// UnmarshalProto replaces the TDigest with the TDigest message in b. Unknown
+// fields are ignored. Centroids must be sorted by increasing mean and have
+// finite means and positive, finite counts. If the sum is missing, it is computed from the centroids.
+func (d *TDigest) UnmarshalProto(b []byte) error {
+	var compression, sum, total float64
+	var hasSum bool
+	var centroids []centroid
+
+	err := readProtoFields(b, func(field uint64, wireType int, fixed uint64, bytes []byte) error {
+		switch {
+		case field == protoTDigestCompression && wireType == protoWireFixed64:
+			compression = math.Float64frombits(fixed)
+		case field == protoTDigestSum && wireType == protoWireFixed64:
+			sum, hasSum = math.Float64frombits(fixed), true
+		case field == protoTDigestCentroids && wireType == protoWireBytes:
+			c, err := unmarshalProtoCentroid(bytes)
+			if err != nil {
+				return err
+			}
+			centroids = append(centroids, c)
+			total += c.count
+		}
+		// The count is recomputed from the centroids.
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := validateCompression(compression); err != nil {
+		return err
+	}
+	if err := validateCentroids(centroids, total); err != nil {
+		return err
+	}
+
+	d.reset()
+	d.compression = compression
+	d.setCentroids(centroids)
+	if hasSum {
+		d.sum = sum
+	}
+	return nil
+}
+
+func unmarshalProtoCentroid(b []byte) (centroid, error) {
+	var c centroid
+	err := readProtoFields(b, func(field uint64, wireType int, fixed uint64, _ []byte) error {
+		switch {
+		case field == protoCentroidMean && wireType == protoWireFixed64:
+			c.mean = math.Float64frombits(fixed)
+		case field == protoCentroidCount && wireType == protoWireFixed64:
+			c.count = math.Float64frombits(fixed)
+		}
+		return nil
+	})
+	if err != nil {
+		return centroid{}, err
+	}
+	return c, nil
+}
+
+// appendProtoDouble appends a double field to b. As in proto3, zero values are
+// omitted.
+func appendProtoDouble(b []byte, field uint64, val float64) []byte {
+	if val == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(b, field<<3|protoWireFixed64)
+	return binary.LittleEndian.AppendUint64(b, math.Float64bits(val))
+}
+
+// readProtoFields calls fn for each field in the message b. For fixed64
+// fields, fixed holds the value. For length-delimited fields, bytes holds the
+// value.
+func readProtoFields(b []byte, fn func(field uint64, wireType int, fixed uint64, bytes []byte) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errTruncatedProto
+		}
+		b = b[n:]
+		field, wireType := tag>>3, int(tag&7)
+
+		var fixed uint64
+		var bytes []byte
+		switch wireType {
+		case protoWireVarint:
+			if _, n = binary.Uvarint(b); n <= 0 {
+				return errTruncatedProto
+			}
+			b = b[n:]
+		case protoWireFixed64:
+			if len(b) < 8 {
+				return errTruncatedProto
+			}
+			fixed = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case protoWireBytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || length > uint64(len(b)-n) {
+				return errTruncatedProto
+			}
+			bytes = b[n : n+int(length)]
+			b = b[n+int(length):]
+		case protoWireFixed32:
+			if len(b) < 4 {
+				return errTruncatedProto
+			}
+			b = b[4:]
+		default:
+			return fmt.Errorf("tdigest: unsupported protocol buffer wire type %d", wireType)
+		}
+
+		if err := fn(field, wireType, fixed, bytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package tdigest_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_MarshalProto(t *testing.T) {
+	digest := newUniformRange(20, 100000, 0, 1, 1)
+
+	b, err := digest.MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := tdigest.New(tdigest.WithCompression(500))
+	if err = decoded.UnmarshalProto(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := mustFloat(decoded.Quantile(0.99)), mustFloat(digest.Quantile(0.99)); got != want {
+		t.Errorf("got Quantile(0.99) = %v, want %v", got, want)
+	}
+	if !decoded.Equal(digest) {
+		t.Errorf("got %v, want %v", decoded, digest)
+	}
+}
+
+func TestTDigest_MarshalProto_Wire(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(1))
+	digest.Add(1)
+	digest.Add(2)
+
+	b, err := digest.MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{
+		// compression = 1
+		0x09, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f,
+		// count = 2
+		0x11, 0, 0, 0, 0, 0, 0, 0, 0x40,
+		// sum = 3
+		0x21, 0, 0, 0, 0, 0, 0, 0x08, 0x40,
+		// centroids {mean: 1, count: 1}
+		0x1a, 18,
+		0x09, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f,
+		0x11, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f,
+		// centroids {mean: 2, count: 1}
+		0x1a, 18,
+		0x09, 0, 0, 0, 0, 0, 0, 0, 0x40,
+		0x11, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f,
+	}
+	if !bytes.Equal(b, want) {
+		t.Errorf("got % x, want % x", b, want)
+	}
+}
+
+func TestTDigest_UnmarshalProto_Malformed(t *testing.T) {
+	b, err := newUniformRange(20, 1000, 0, 1, 1).MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Truncating inside any field is an error. Truncating between fields is a
+	// valid message with fewer fields.
+	for i := 1; i < 18; i++ {
+		if i == 9 {
+			continue
+		}
+		if err = tdigest.New(tdigest.WithCompression(100)).UnmarshalProto(b[:i]); err == nil {
+			t.Errorf("got no error unmarshalling %d bytes", i)
+		}
+	}
+
+	unsorted := []byte{
+		0x1a, 18,
+		0x09, 0, 0, 0, 0, 0, 0, 0, 0x40,
+		0x11, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f,
+		0x1a, 18,
+		0x09, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f,
+		0x11, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f,
+	}
+	if err = tdigest.New(tdigest.WithCompression(100)).UnmarshalProto(unsorted); err == nil {
+		t.Error("got no error unmarshalling unsorted centroids")
+	}
+
+	zeroCount := []byte{0x1a, 9, 0x09, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f}
+	if err = tdigest.New(tdigest.WithCompression(100)).UnmarshalProto(zeroCount); err == nil {
+		t.Error("got no error unmarshalling centroid without count")
+	}
+	// compression = 100, then one centroid with the given mean and count.
+	centroid := func(mean, count float64) []byte {
+		b := []byte{0x09, 0, 0, 0, 0, 0, 0, 0x59, 0x40, 0x1a, 18, 0x09}
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(mean))
+		b = append(b, 0x11)
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(count))
+	}
+	if err = tdigest.New().UnmarshalProto(centroid(1, 1)); err != nil {
+		t.Fatalf("got error %v unmarshalling valid centroid", err)
+	}
+	for _, tc := range []struct {
+		mean, count float64
+	}{
+		{mean: math.NaN(), count: 1},
+		{mean: math.Inf(1), count: 1},
+		{mean: 1, count: math.Inf(1)},
+		{mean: 1, count: math.NaN()},
+	} {
+		if err = tdigest.New().UnmarshalProto(centroid(tc.mean, tc.count)); err == nil {
+			t.Errorf("got no error unmarshalling centroid with mean %v and count %v", tc.mean, tc.count)
+		}
+	}
+}
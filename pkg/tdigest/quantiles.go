@@ -0,0 +1,82 @@
+package tdigest
+
+import (
+	"math"
+	"sort"
+)
+
+// Quantiles returns the estimated value of each of qs, in the same order as
+// qs, or ErrEmptyDigest if the TDigest is empty. Values of qs outside [0, 1]
+// are clamped, as in Quantile.
+//
+// The centroids are swept once for all of qs, so for many quantiles this is
+// faster than calling Quantile for each.
+func (d *TDigest) Quantiles(qs []float64) ([]float64, error) {
+	if d.nCentroids == 0 {
+		return nil, ErrEmptyDigest
+	}
+
+	result := make([]float64, len(qs))
+	n := len(d.centroids)
+	if n <= binarySearchThreshold {
+		// Few centroids are cheap to scan for each quantile.
+		for i, q := range qs {
+			result[i] = d.quantile(q)
+		}
+		return result, nil
+	}
+
+	// Visit qs in increasing order so each centroid is passed at most once.
+	// NaNs have no order, so they are answered individually.
+	order := make([]int, 0, len(qs))
+	for i, q := range qs {
+		if math.IsNaN(q) {
+			result[i] = d.quantile(q)
+			continue
+		}
+		order = append(order, i)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return qs[order[i]] < qs[order[j]]
+	})
+
+	var qTotal float64
+	idx := 0
+	for _, i := range order {
+		q := qs[i]
+		if q <= 0 {
+			result[i] = d.min
+			continue
+		} else if q >= 1 {
+			result[i] = d.max
+			continue
+		}
+		// rescale into count units.
+		q *= d.count
+
+		for idx < n-1 && qTotal+d.centroids[idx].count/2 < q {
+			qTotal += d.centroids[idx].count
+			idx++
+		}
+		result[i] = d.clamp(d.interpolateAt(q, idx, qTotal))
+	}
+	return result, nil
+}
+
+// Percentile returns the estimated value of percentile p, Quantile(p / 100), or
+// ErrEmptyDigest if the TDigest is empty. p is clamped to [0, 100], so
+// Percentile(-5) is Min and Percentile(150) is Max.
+func (d *TDigest) Percentile(p float64) (float64, error) {
+	return d.Quantile(p / 100)
+}
+
+// Percentiles returns the estimated value of each of ps, in the same order as
+// ps, as Quantiles does for ps divided by 100, or ErrEmptyDigest if the TDigest
+// is empty. Values of ps outside [0, 100] are clamped, as in Percentile.
+func (d *TDigest) Percentiles(ps []float64) ([]float64, error) {
+	qs := make([]float64, len(ps))
+	for i, p := range ps {
+		qs[i] = p / 100
+	}
+	return d.Quantiles(qs)
+}
@@ -0,0 +1,97 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_Quantiles(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	qs := []float64{0.5, -1, 0.99, 0, 1, 2, 0.01, 0.5, math.NaN()}
+	for i := 0; i < 100; i++ {
+		qs = append(qs, r.Float64())
+	}
+
+	tcs := []struct {
+		name   string
+		digest *tdigest.TDigest
+	}{{
+		name:   "few centroids",
+		digest: newUniformRange(1000, 3000, 0, 1, 1),
+	}, {
+		name:   "many centroids",
+		digest: newUniformRange(20, 100000, 0, 1, 1),
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.digest.Quantiles(qs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(qs) {
+				t.Fatalf("got %d quantiles, want %d", len(got), len(qs))
+			}
+			for i, q := range qs {
+				want := mustFloat(tc.digest.Quantile(q))
+				if got[i] != want && !(math.IsNaN(got[i]) && math.IsNaN(want)) {
+					t.Errorf("got Quantiles()[%d] = %v for q = %v, want %v", i, got[i], q, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTDigest_Quantiles_Empty(t *testing.T) {
+	got, err := tdigest.New(tdigest.WithCompression(20)).Quantiles([]float64{0.5})
+	if got != nil || err != tdigest.ErrEmptyDigest {
+		t.Errorf("got Quantiles() = %v, %v for empty digest, want nil, ErrEmptyDigest", got, err)
+	}
+}
+
+func TestTDigest_Percentiles(t *testing.T) {
+	digest := newUniformRange(20, 100000, 0, 1, 1)
+	ps := []float64{50, 90, 99, 99.9, -5, 150}
+
+	got, err := digest.Percentiles(ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range ps {
+		want := mustFloat(digest.Quantile(p / 100))
+		if got[i] != want {
+			t.Errorf("got Percentiles()[%d] = %v for p = %v, want %v", i, got[i], p, want)
+		}
+		if single := mustFloat(digest.Percentile(p)); single != want {
+			t.Errorf("got Percentile(%v) = %v, want %v", p, single, want)
+		}
+	}
+	if got[4] != mustFloat(digest.Min()) || got[5] != mustFloat(digest.Max()) {
+		t.Errorf("got %v and %v for percentiles outside [0, 100], want Min and Max", got[4], got[5])
+	}
+}
+
+var benchmarkQs = []float64{0.5, 0.9, 0.95, 0.99, 0.999, 0.1, 0.25, 0.75, 0.01, 0.05}
+
+func BenchmarkTDigest_Quantiles(b *testing.B) {
+	digest := newUniformRange(20, 100000, 0, 1, 1)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = digest.Quantiles(benchmarkQs)
+	}
+}
+
+func BenchmarkTDigest_Quantile_Loop(b *testing.B) {
+	digest := newUniformRange(20, 100000, 0, 1, 1)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, q := range benchmarkQs {
+			_ = mustFloat(digest.Quantile(q))
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package tdigest
+
+import (
+	"math"
+	"time"
+)
+
+// RateLimitedDigest is a TDigest which accepts at most a fixed number of
+// observations per second, dropping the rest. This bounds the cost of
+// extremely high-rate telemetry at the expense of sampling.
+//
+// Observations are accepted using a token bucket holding up to one second of
+// observations, so short bursts above the rate are accepted.
+type RateLimitedDigest struct {
+	digest *TDigest
+
+	maxOpsPerSec float64
+	tokens       float64
+	lastRefill   time.Time
+
+	// now returns the current time. Replaced in tests.
+	now func() time.Time
+
+	accepted uint64
+	dropped  uint64
+}
+
+// NewRateLimitedDigest returns an empty RateLimitedDigest with the given
+// compression which accepts at most maxOpsPerSec observations per second.
+func NewRateLimitedDigest(compression float64, maxOpsPerSec float64) *RateLimitedDigest {
+	d := &RateLimitedDigest{
+		digest:       New(WithCompression(compression)),
+		maxOpsPerSec: maxOpsPerSec,
+		now:          time.Now,
+	}
+	d.tokens = d.capacity()
+	d.lastRefill = d.now()
+	return d
+}
+
+// capacity returns the maximum number of tokens in the bucket.
+func (d *RateLimitedDigest) capacity() float64 {
+	return math.Max(1, d.maxOpsPerSec)
+}
+
+// Add adds val unless the rate limit has been exceeded. It returns false if val
+// was dropped. If val is NaN or infinite, it returns ErrInvalidValue without
+// using up the rate limit.
+func (d *RateLimitedDigest) Add(val float64) (bool, error) {
+	if !isValid(val) {
+		return false, ErrInvalidValue
+	}
+
+	now := d.now()
+	d.tokens += now.Sub(d.lastRefill).Seconds() * d.maxOpsPerSec
+	if capacity := d.capacity(); d.tokens > capacity {
+		d.tokens = capacity
+	}
+	d.lastRefill = now
+
+	if d.tokens < 1 {
+		d.dropped++
+		return false, nil
+	}
+	d.tokens--
+	d.accepted++
+	// val was validated above, so Add can't fail.
+	_ = d.digest.Add(val)
+	return true, nil
+}
+
+// Digest returns the TDigest of accepted observations.
+func (d *RateLimitedDigest) Digest() *TDigest {
+	return d.digest
+}
+
+// Quantile returns the quantile q of accepted observations, or ErrEmptyDigest
+// if none have been accepted.
+func (d *RateLimitedDigest) Quantile(q float64) (float64, error) {
+	return d.digest.Quantile(q)
+}
+
+// DroppedCount returns the number of dropped observations.
+func (d *RateLimitedDigest) DroppedCount() uint64 {
+	return d.dropped
+}
+
+// EffectiveSampleRate returns the fraction of observations which were
+// accepted. It is 1 if no observations have been added.
+func (d *RateLimitedDigest) EffectiveSampleRate() float64 {
+	total := d.accepted + d.dropped
+	if total == 0 {
+		return 1
+	}
+	return float64(d.accepted) / float64(total)
+}
@@ -0,0 +1,86 @@
+package tdigest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func newFakeRateLimitedDigest(clock *fakeClock, maxOpsPerSec float64) *RateLimitedDigest {
+	d := NewRateLimitedDigest(100, maxOpsPerSec)
+	d.now = clock.now
+	d.lastRefill = clock.now()
+	return d
+}
+
+func TestRateLimitedDigest(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	d := newFakeRateLimitedDigest(clock, 1000)
+	r := rand.New(rand.NewSource(1))
+
+	// Add at twice the rate limit for 100 seconds.
+	var accepted uint64
+	for i := 0; i < 200000; i++ {
+		ok, err := d.Add(r.Float64())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			accepted++
+		}
+		clock.advance(500 * time.Microsecond)
+	}
+
+	if got := d.DroppedCount(); got+accepted != 200000 {
+		t.Errorf("got DroppedCount() = %v with %v accepted, want %v total", got, accepted, 200000)
+	}
+	if got := d.EffectiveSampleRate(); math.Abs(got-0.5) > 0.01 {
+		t.Errorf("got EffectiveSampleRate() = %v, want approximately 0.5", got)
+	}
+	if got := d.Digest().count; got != float64(accepted) {
+		t.Errorf("got count %v, want %v", got, accepted)
+	}
+
+	// The accepted observations are still a fair sample.
+	for _, q := range []float64{0.1, 0.5, 0.9} {
+		if got, err := d.Quantile(q); err != nil || math.Abs(got-q) > 0.02 {
+			t.Errorf("got Quantile(%v) = %v, want approximately %v", q, got, q)
+		}
+	}
+}
+
+func TestRateLimitedDigest_UnderLimit(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	d := newFakeRateLimitedDigest(clock, 1000)
+
+	if got := d.EffectiveSampleRate(); got != 1 {
+		t.Errorf("got EffectiveSampleRate() = %v with no observations, want 1", got)
+	}
+
+	for i := 0; i < 10000; i++ {
+		if ok, err := d.Add(float64(i)); err != nil || !ok {
+			t.Fatalf("got observation %d dropped under the rate limit", i)
+		}
+		clock.advance(2 * time.Millisecond)
+	}
+	if got := d.DroppedCount(); got != 0 {
+		t.Errorf("got DroppedCount() = %v, want 0", got)
+	}
+}
+
+func TestRateLimitedDigest_Add_Invalid(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	d := newFakeRateLimitedDigest(clock, 1)
+
+	if ok, err := d.Add(math.NaN()); ok || err != ErrInvalidValue {
+		t.Errorf("got Add(NaN) = %v, %v, want false, %v", ok, err, ErrInvalidValue)
+	}
+	// The invalid value didn't use up the only token.
+	if ok, err := d.Add(1); !ok || err != nil {
+		t.Errorf("got Add(1) = %v, %v, want true, nil", ok, err)
+	}
+	if got := d.DroppedCount(); got != 0 {
+		t.Errorf("got DroppedCount() = %v, want 0", got)
+	}
+}
@@ -0,0 +1,104 @@
+package tdigest
+
+// Recompress merges neighboring centroids as long as each merged centroid is
+// within the limit Add gives it, as set by the compression and ScaleFunc. The
+// TDigest then has fewer centroids to search, a LoadFactor of at most 1, and
+// about the accuracy it had before.
+//
+// As in the merge step of the t-digest paper, centroids are merged greedily
+// from lowest to highest mean. Limits grow with the number of centroids, so
+// merging uses the limits of as many centroids as it leaves. Centroids near
+// the extremes therefore stay smaller than those near the median, preserving
+// the accuracy of extreme quantiles.
+//
+// The count, minimum, and maximum are unchanged.
+func (d *TDigest) Recompress() {
+	if d.nCentroids < 2 {
+		return
+	}
+
+	d.centroids = d.compressCentroids(d.centroids, d.count)
+	d.nCentroids = len(d.centroids)
+	d.prefixValid = false
+	d.p5Centroid = 0
+	d.p95Centroid = 0
+	d.cachePercentileCentroids()
+}
+
+// compressCentroids merges neighboring centroids, sorted by increasing mean
+// and with counts summing to count, as Recompress does. It merges in place
+// and returns the merged prefix of centroids.
+func (d *TDigest) compressCentroids(centroids []centroid, count float64) []centroid {
+	if len(centroids) < 2 {
+		return centroids
+	}
+
+	// Merging within the limits of more centroids leaves fewer of them. Find
+	// the most centroids, n, whose limits leave at least n, so each merged
+	// centroid is within the limit of the centroids actually left. n is
+	// usually far less than len(centroids), and counting stops at n, so
+	// search up from 1.
+	lo, hi := 1, 2
+	for hi < len(centroids) && d.countMerged(centroids, count, hi) >= hi {
+		lo, hi = hi, 2*hi
+	}
+	if hi > len(centroids) {
+		hi = len(centroids)
+	}
+	for lo < hi {
+		mid := hi - (hi-lo)/2
+		if d.countMerged(centroids, count, mid) >= mid {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	n := lo
+
+	// Merge in place, reusing the lowest centroid of each merged run.
+	merged := centroids[:1]
+	cur := &merged[0]
+	cur.maxCount, cur.nCentroids = 0, 0
+	// start is the number of observations below cur.
+	start := 0.0
+	for _, c := range centroids[1:] {
+		if d.fits(start, cur.count+c.count, count, n) {
+			cur.incN(c.mean, c.count)
+			continue
+		}
+
+		start += cur.count
+		// The cached limit no longer applies.
+		c.maxCount, c.nCentroids = 0, 0
+		merged = append(merged, c)
+		cur = &merged[len(merged)-1]
+	}
+	return merged
+}
+
+// countMerged returns how many centroids compressCentroids would leave if it
+// merged within the limits of n centroids. It stops counting at n.
+func (d *TDigest) countMerged(centroids []centroid, count float64, n int) int {
+	merged := 1
+	start, cur := 0.0, centroids[0].count
+	for _, c := range centroids[1:] {
+		if d.fits(start, cur+c.count, count, n) {
+			cur += c.count
+			continue
+		}
+
+		merged++
+		if merged == n {
+			return merged
+		}
+		start += cur
+		cur = c.count
+	}
+	return merged
+}
+
+// fits returns whether a centroid of size observations, with start of the
+// count observations below it, is within the limit of n centroids.
+func (d *TDigest) fits(start, size, count float64, n int) bool {
+	return size <= d.limit((start+size/2)/count, n)
+}
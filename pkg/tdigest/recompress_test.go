@@ -0,0 +1,158 @@
+package tdigest_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+// newUncompressed returns a TDigest with one centroid for each of n normally
+// distributed values, and the values in the order they were drawn.
+func newUncompressed(compression float64, n int) (*tdigest.TDigest, []float64) {
+	r := rand.New(rand.NewSource(1))
+	vals := make([]float64, n)
+	for i := range vals {
+		vals[i] = r.NormFloat64()
+	}
+
+	data := make([]tdigest.CentroidData, n)
+	for i, val := range vals {
+		data[i] = tdigest.CentroidData{Mean: val, Count: 1}
+	}
+	sort.Slice(data, func(i, j int) bool {
+		return data[i].Mean < data[j].Mean
+	})
+	digest, err := tdigest.FromCentroids(compression, data)
+	if err != nil {
+		panic(err)
+	}
+	return digest, vals
+}
+
+// rankError returns how far the rank of estimate in sorted is from q.
+func rankError(sorted []float64, q, estimate float64) float64 {
+	rank := float64(sort.SearchFloat64s(sorted, estimate)) / float64(len(sorted))
+	return math.Abs(rank - q)
+}
+
+func TestTDigest_Recompress(t *testing.T) {
+	for _, compression := range []float64{1, 10, 20} {
+		t.Run(fmt.Sprint(compression), func(t *testing.T) {
+			digest, vals := newUncompressed(compression, 100000)
+			count := digest.Count()
+			min, max := mustFloat(digest.Min()), mustFloat(digest.Max())
+			mean := mustFloat(digest.Mean())
+
+			digest.Recompress()
+
+			if err := digest.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if got := digest.LoadFactor(); got < 0.95 {
+				t.Errorf("got LoadFactor() = %v after Recompress, want at least 0.95", got)
+			}
+			if got := digest.Count(); got != count {
+				t.Errorf("got Count() = %v, want %v", got, count)
+			}
+			if got := mustFloat(digest.Min()); got != min {
+				t.Errorf("got Min() = %v, want %v", got, min)
+			}
+			if got := mustFloat(digest.Max()); got != max {
+				t.Errorf("got Max() = %v, want %v", got, max)
+			}
+			if got := mustFloat(digest.Mean()); math.Abs(got-mean) > 1e-9 {
+				t.Errorf("got Mean() = %v, want %v", got, mean)
+			}
+
+			// Recompress uses the limits Add does, so it keeps no more
+			// centroids and is about as accurate.
+			added := tdigest.New(tdigest.WithCompression(compression))
+			added.AddAll(vals)
+			if got, want := digest.CentroidCount(), added.CentroidCount(); got > want {
+				t.Errorf("got CentroidCount() = %v, want at most %v as with Add", got, want)
+			}
+			sort.Float64s(vals)
+			for _, q := range []float64{0.001, 0.01, 0.1, 0.5, 0.9, 0.99, 0.999} {
+				got := rankError(vals, q, mustFloat(digest.Quantile(q)))
+				want := rankError(vals, q, mustFloat(added.Quantile(q)))
+				if got > 2*want+0.0005 {
+					t.Errorf("got Quantile(%v) rank error %v, want about %v as with Add", q, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTDigest_Recompress_Accuracy(t *testing.T) {
+	// Recompressing a TDigest built with Add keeps its accuracy.
+	for _, compression := range []float64{1, 10, 100} {
+		_, vals := newUncompressed(compression, 100000)
+		digest := tdigest.New(tdigest.WithCompression(compression))
+		digest.AddAll(vals)
+		recompressed := digest.Clone()
+		recompressed.Recompress()
+
+		sort.Float64s(vals)
+		for _, q := range []float64{0.001, 0.01, 0.5, 0.99, 0.999} {
+			got := rankError(vals, q, mustFloat(recompressed.Quantile(q)))
+			want := rankError(vals, q, mustFloat(digest.Quantile(q)))
+			if got > want+0.0005 {
+				t.Errorf("got Quantile(%v) rank error %v after Recompress at compression %v, want %v as before",
+					q, got, compression, want)
+			}
+		}
+	}
+}
+
+func TestTDigest_Recompress_Small(t *testing.T) {
+	digest, _ := newUncompressed(20, 1000)
+	digest.Recompress()
+	want := digest.String()
+
+	// A recompressed TDigest is unchanged by recompressing again.
+	digest.Recompress()
+	if got := digest.String(); got != want {
+		t.Errorf("got %v, want unchanged %v", got, want)
+	}
+
+	// Merged centroids accept new values.
+	uncompressed, vals := newUncompressed(20, 10000)
+	uncompressed.Recompress()
+	uncompressed.AddAll(vals[:1000])
+	if err := uncompressed.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	empty := tdigest.New()
+	empty.Recompress()
+	if got := empty.Count(); got != 0 {
+		t.Errorf("got Count() = %v, want 0", got)
+	}
+}
+
+func BenchmarkTDigest_Quantile_Recompress(b *testing.B) {
+	uncompressed, _ := newUncompressed(20, 10000)
+	recompressed := uncompressed.Clone()
+	recompressed.Recompress()
+
+	for _, tc := range []struct {
+		name   string
+		digest *tdigest.TDigest
+	}{{
+		name:   "uncompressed",
+		digest: uncompressed,
+	}, {
+		name:   "recompressed",
+		digest: recompressed,
+	}} {
+		b.Run(tc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = tc.digest.Quantile(0.99)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package tdigest
+
+// LinearRegression fits the line Y = slope*X + intercept through the paired
+// quantiles of the TDigest (X) and other (Y) at q = 0.01, 0.02, ..., 0.99,
+// using ordinary least squares.
+//
+// r2 is the coefficient of determination of the fit. Near 1, the two
+// distributions are related by a linear transformation, for example CPU usage
+// scaling linearly with request rate.
+//
+// If every quantile of the TDigest is the same, for example because it holds
+// a single distinct value, no line can be fit, so all three results are NaN.
+// r2 is also NaN if every quantile of other is the same. Results are NaN if
+// either TDigest is empty.
+func (d *TDigest) LinearRegression(other *TDigest) (slope, intercept, r2 float64) {
+	const n = 99
+
+	var xs, ys [n]float64
+	var sumX, sumY float64
+	for i := range xs {
+		q := float64(i+1) / 100
+		xs[i] = d.quantile(q)
+		ys[i] = other.quantile(q)
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var sxx, sxy, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+
+	slope = sxy / sxx
+	intercept = meanY - slope*meanX
+	r2 = sxy * sxy / (sxx * syy)
+	return slope, intercept, r2
+}
@@ -0,0 +1,46 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_LinearRegression(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	x := tdigest.New(tdigest.WithCompression(20))
+	y := tdigest.New(tdigest.WithCompression(20))
+	for i := 0; i < 100000; i++ {
+		val := math.Exp(r.NormFloat64())
+		x.Add(val)
+		y.Add(2 * val)
+	}
+
+	slope, intercept, r2 := x.LinearRegression(y)
+	if math.Abs(slope-2) > 0.01 {
+		t.Errorf("got slope %v, want approximately 2", slope)
+	}
+	if math.Abs(intercept) > 0.01 {
+		t.Errorf("got intercept %v, want approximately 0", intercept)
+	}
+	if r2 < 0.999 {
+		t.Errorf("got r2 %v, want approximately 1", r2)
+	}
+}
+
+func TestTDigest_LinearRegression_Nonlinear(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	x := tdigest.New(tdigest.WithCompression(20))
+	y := tdigest.New(tdigest.WithCompression(20))
+	for i := 0; i < 100000; i++ {
+		val := r.Float64()
+		x.Add(val)
+		y.Add(math.Exp(10 * val))
+	}
+
+	if _, _, r2 := x.LinearRegression(y); r2 > 0.9 {
+		t.Errorf("got r2 %v for exponential relationship, want less than 0.9", r2)
+	}
+}
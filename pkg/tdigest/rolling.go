@@ -0,0 +1,72 @@
+package tdigest
+
+import "fmt"
+
+// RollingDigest is a sliding window over the most recent observations which
+// advances stepSize observations at a time.
+//
+// The window is made of windowSize/stepSize sub-digests of stepSize
+// observations each, plus the sub-digest currently being filled. So, the
+// window covers between windowSize and windowSize+stepSize-1 observations once
+// it has filled.
+type RollingDigest struct {
+	stepSize int
+	maxSteps int
+
+	current      *TDigest
+	currentCount int
+
+	// steps are the filled sub-digests, from oldest to newest.
+	steps []*TDigest
+}
+
+// NewRollingDigest panics if stepSize is not positive or windowSize is smaller
+// than stepSize.
+func NewRollingDigest(compression float64, windowSize, stepSize int) *RollingDigest {
+	if stepSize <= 0 {
+		panic(fmt.Sprintf("tdigest: step size must be positive, got %d", stepSize))
+	}
+	if windowSize < stepSize {
+		panic(fmt.Sprintf("tdigest: window size %d is smaller than step size %d", windowSize, stepSize))
+	}
+
+	maxSteps := windowSize / stepSize
+	return &RollingDigest{
+		stepSize: stepSize,
+		maxSteps: maxSteps,
+		current:  New(WithCompression(compression)),
+		steps:    make([]*TDigest, 0, maxSteps),
+	}
+}
+
+// Add adds val to the current sub-digest, advancing the window if the
+// sub-digest is full. It returns ErrInvalidValue if val is NaN or infinite.
+func (d *RollingDigest) Add(val float64) error {
+	if err := d.current.Add(val); err != nil {
+		return err
+	}
+	d.currentCount++
+	if d.currentCount < d.stepSize {
+		return nil
+	}
+
+	if len(d.steps) == d.maxSteps {
+		copy(d.steps, d.steps[1:])
+		d.steps = d.steps[:d.maxSteps-1]
+	}
+	d.steps = append(d.steps, d.current)
+	d.current = New(WithCompression(d.current.Compression()))
+	d.currentCount = 0
+	return nil
+}
+
+// Quantile returns the quantile q of the observations in the window, or
+// ErrEmptyDigest if the window is empty.
+func (d *RollingDigest) Quantile(q float64) (float64, error) {
+	merged := New(WithCompression(d.current.Compression()))
+	for _, step := range d.steps {
+		merged.Accumulate(step)
+	}
+	merged.Accumulate(d.current)
+	return merged.Quantile(q)
+}
@@ -0,0 +1,70 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestRollingDigest(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.NewRollingDigest(20, 10000, 1000)
+
+	// Fill the window with uniform [0, 1]. As it fills, the median settles at
+	// 0.5.
+	for i := 0; i < 10000; i++ {
+		digest.Add(r.Float64())
+	}
+	if got := mustFloat(digest.Quantile(0.5)); math.Abs(got-0.5) > 0.02 {
+		t.Errorf("got Quantile(0.5) = %v, want approximately 0.5", got)
+	}
+
+	// Shift the distribution to uniform [1, 2] one step at a time. The median
+	// should steadily increase as old steps leave the window.
+	prev := mustFloat(digest.Quantile(0.5))
+	for step := 0; step < 10; step++ {
+		for i := 0; i < 1000; i++ {
+			digest.Add(1 + r.Float64())
+		}
+
+		got := mustFloat(digest.Quantile(0.5))
+		if got < prev {
+			t.Errorf("step %d: got Quantile(0.5) = %v, want at least %v", step, got, prev)
+		}
+		// Each step replaces a tenth of the window, so the median can't jump
+		// by more than about 0.2.
+		if got-prev > 0.25 {
+			t.Errorf("step %d: got Quantile(0.5) = %v, jumped from %v", step, got, prev)
+		}
+		prev = got
+	}
+
+	// The window has entirely moved to uniform [1, 2].
+	if got := mustFloat(digest.Quantile(0.5)); math.Abs(got-1.5) > 0.02 {
+		t.Errorf("got Quantile(0.5) = %v, want approximately 1.5", got)
+	}
+	if got := mustFloat(digest.Quantile(0.01)); got < 1 {
+		t.Errorf("got Quantile(0.01) = %v, want at least 1", got)
+	}
+}
+
+func TestNewRollingDigest_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		name                 string
+		windowSize, stepSize int
+	}{
+		{"zero step", 100, 0},
+		{"window smaller than step", 10, 100},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("got no panic")
+				}
+			}()
+			tdigest.NewRollingDigest(100, tc.windowSize, tc.stepSize)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package tdigest
+
+import "math/rand"
+
+// Sample returns a random value drawn from the distribution estimated by the
+// TDigest, or ErrEmptyDigest if the TDigest is empty. It draws a quantile
+// uniformly with rng and returns its estimated value.
+//
+// Samples follow the estimated distribution, not the exact observations. In
+// particular, values are interpolated between centroids, so samples may take
+// values which were never observed, and distributions summarized by few
+// centroids are smoothed.
+func (d *TDigest) Sample(rng *rand.Rand) (float64, error) {
+	return d.Quantile(rng.Float64())
+}
+
+// Samples returns n random values drawn from the distribution estimated by the
+// TDigest, as by Sample, or ErrEmptyDigest if the TDigest is empty.
+//
+// The values are computed with Quantiles, so this is faster than calling
+// Sample n times.
+func (d *TDigest) Samples(rng *rand.Rand, n int) ([]float64, error) {
+	if d.nCentroids == 0 {
+		return nil, ErrEmptyDigest
+	}
+	qs := make([]float64, n)
+	for i := range qs {
+		qs[i] = rng.Float64()
+	}
+	return d.Quantiles(qs)
+}
+
+// AppendTo appends n random values drawn from the distribution estimated by
+// the TDigest, as by Sample, to dst and returns the extended slice. If the
+// TDigest is empty, it returns dst unchanged and ErrEmptyDigest.
+//
+// Unlike Samples, it allocates nothing if dst has capacity for n more values.
+func (d *TDigest) AppendTo(dst []float64, rng *rand.Rand, n int) ([]float64, error) {
+	if d.nCentroids == 0 {
+		return dst, ErrEmptyDigest
+	}
+	for i := 0; i < n; i++ {
+		dst = append(dst, d.quantile(rng.Float64()))
+	}
+	return dst, nil
+}
@@ -0,0 +1,98 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_Samples(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.New(tdigest.WithCompression(5))
+	for i := 0; i < 100000; i++ {
+		digest.Add(r.ExpFloat64())
+	}
+
+	samples, err := digest.Samples(rand.New(rand.NewSource(2)), 100000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Float64s(samples)
+
+	// The empirical CDF of the samples matches the digest's CDF.
+	for _, q := range []float64{0.01, 0.05, 0.1, 0.25, 0.5, 0.75, 0.9, 0.95, 0.99} {
+		x := mustFloat(digest.Quantile(q))
+		want := mustFloat(digest.CDF(x))
+		got := float64(sort.SearchFloat64s(samples, x)) / float64(len(samples))
+		if math.Abs(got-want) > 0.01 {
+			t.Errorf("got empirical CDF(%v) = %v, want %v +/- 0.01", x, got, want)
+		}
+	}
+
+	min, max := mustFloat(digest.Min()), mustFloat(digest.Max())
+	if samples[0] < min || samples[len(samples)-1] > max {
+		t.Errorf("got samples in [%v, %v], want within [%v, %v]", samples[0], samples[len(samples)-1], min, max)
+	}
+}
+
+func TestTDigest_Sample(t *testing.T) {
+	digest := newUniformRange(20, 10000, 0, 1, 1)
+
+	// Sample draws the same quantile as Samples given the same source.
+	got := mustFloat(digest.Sample(rand.New(rand.NewSource(1))))
+	samples, err := digest.Samples(rand.New(rand.NewSource(1)), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != samples[0] {
+		t.Errorf("got Sample() = %v, want %v", got, samples[0])
+	}
+
+	empty := tdigest.New()
+	if _, err := empty.Sample(rand.New(rand.NewSource(1))); err != tdigest.ErrEmptyDigest {
+		t.Errorf("got error %v from Sample on empty TDigest, want %v", err, tdigest.ErrEmptyDigest)
+	}
+	if _, err := empty.Samples(rand.New(rand.NewSource(1)), 10); err != tdigest.ErrEmptyDigest {
+		t.Errorf("got error %v from Samples on empty TDigest, want %v", err, tdigest.ErrEmptyDigest)
+	}
+}
+
+func TestTDigest_AppendTo(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.New(tdigest.WithCompression(5))
+	for i := 0; i < 100000; i++ {
+		digest.Add(r.NormFloat64())
+	}
+
+	const n = 1000000
+	prefix := []float64{-100, 100}
+	dst := make([]float64, len(prefix), len(prefix)+n)
+	copy(dst, prefix)
+	got, err := digest.AppendTo(dst, rand.New(rand.NewSource(2)), n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(prefix)+n || &got[0] != &dst[0] {
+		t.Fatalf("got %d values in a new slice, want %d appended in place", len(got), len(prefix)+n)
+	}
+	if got[0] != prefix[0] || got[1] != prefix[1] {
+		t.Errorf("got prefix %v, want %v", got[:2], prefix)
+	}
+
+	samples := got[len(prefix):]
+	sort.Float64s(samples)
+	for _, q := range []float64{0.01, 0.99} {
+		want := mustFloat(digest.Quantile(q))
+		if got := samples[int(q*n)]; math.Abs(got-want) > 0.02*math.Abs(want) {
+			t.Errorf("got sample quantile %v = %v, want %v +/- 2%%", q, got, want)
+		}
+	}
+
+	empty := tdigest.New()
+	if got, err := empty.AppendTo(prefix, rand.New(rand.NewSource(1)), 10); err != tdigest.ErrEmptyDigest || len(got) != len(prefix) {
+		t.Errorf("got %v, %v from AppendTo on empty TDigest, want %v, %v", got, err, prefix, tdigest.ErrEmptyDigest)
+	}
+}
@@ -0,0 +1,44 @@
+package tdigest
+
+import "math"
+
+// ScaleFunc returns the most observations a centroid at quantile q may hold,
+// given the compression and the number of centroids in the TDigest. It sets
+// the trade-off between accuracy, which is best where the limit is small, and
+// the number of centroids.
+//
+// The built-in ScaleFuncs are named after the scale functions of the t-digest
+// paper which they derive from. Each limit is proportional to the inverse of
+// the scale function's derivative, and is compression*nCentroids at the
+// median.
+type ScaleFunc func(q, compression float64, nCentroids int) float64
+
+var (
+	// ScaleFuncK1 derives from k1, compression/2π * arcsin(2q-1). Its limit
+	// shrinks slowly towards the tails, so it uses the fewest centroids but is
+	// the least accurate at extreme quantiles.
+	ScaleFuncK1 ScaleFunc = scaleK1
+
+	// ScaleFuncK2 is the default, with a limit proportional to q(1-q).
+	ScaleFuncK2 ScaleFunc = scaleK2
+
+	// ScaleFuncK3 derives from k3, whose limit is piecewise linear in q:
+	// proportional to min(q, 1-q). It uses the most centroids and is the most
+	// accurate at extreme quantiles.
+	ScaleFuncK3 ScaleFunc = scaleK3
+)
+
+func scaleK1(q, compression float64, nCentroids int) float64 {
+	return 2 * compression * math.Sqrt(q*(1-q)) * float64(nCentroids)
+}
+
+func scaleK2(q, compression float64, nCentroids int) float64 {
+	return 4 * compression * q * (1 - q) * float64(nCentroids)
+}
+
+func scaleK3(q, compression float64, nCentroids int) float64 {
+	if q > 0.5 {
+		q = 1 - q
+	}
+	return 2 * compression * q * float64(nCentroids)
+}
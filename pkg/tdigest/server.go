@@ -0,0 +1,213 @@
+package tdigest
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// snapshotInterval is how often DigestServer sends a QuantileSnapshot to each
+// connected query client.
+const snapshotInterval = 100 * time.Millisecond
+
+// snapshotQuantiles are the quantiles reported in each QuantileSnapshot.
+var snapshotQuantiles = []float64{0.5, 0.9, 0.95, 0.99, 0.999}
+
+// QuantileValue is the estimated value of a single quantile.
+type QuantileValue struct {
+	Q     float64 `json:"q"`
+	Value float64 `json:"value"`
+}
+
+// QuantileSnapshot is a summary of a TDigest at a point in time.
+type QuantileSnapshot struct {
+	Count     float64         `json:"count"`
+	Quantiles []QuantileValue `json:"quantiles"`
+}
+
+// WriteTo writes the snapshot as a single line of JSON.
+func (s *QuantileSnapshot) WriteTo(w io.Writer) (int64, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(b, '\n'))
+	return int64(n), err
+}
+
+// DigestServer aggregates float64 observations sent over TCP into a shared
+// TDigest.
+//
+// Clients connected to AddEndpoint send observations as newline-delimited
+// decimal numbers. Lines which can't be parsed are ignored. Clients connected
+// to QueryEndpoint periodically receive a QuantileSnapshot of the aggregated
+// TDigest as newline-delimited JSON.
+type DigestServer struct {
+	mu     sync.Mutex
+	digest *TDigest
+
+	addListener   net.Listener
+	queryListener net.Listener
+
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{}
+
+	done         chan struct{}
+	shutdownOnce sync.Once
+	wg           sync.WaitGroup
+}
+
+// StartServer starts a DigestServer accepting observations on addr. Queries
+// are accepted on a separate port of the same host.
+func StartServer(addr string, compression float64) (*DigestServer, error) {
+	addListener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _, err := net.SplitHostPort(addListener.Addr().String())
+	if err != nil {
+		_ = addListener.Close()
+		return nil, err
+	}
+	queryListener, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		_ = addListener.Close()
+		return nil, err
+	}
+
+	s := &DigestServer{
+		digest:        New(WithCompression(compression)),
+		addListener:   addListener,
+		queryListener: queryListener,
+		conns:         make(map[net.Conn]struct{}),
+		done:          make(chan struct{}),
+	}
+
+	s.wg.Add(2)
+	go s.accept(addListener, s.handleAdd)
+	go s.accept(queryListener, s.handleQuery)
+
+	return s, nil
+}
+
+// AddEndpoint returns the address clients send observations to.
+func (s *DigestServer) AddEndpoint() string {
+	return s.addListener.Addr().String()
+}
+
+// QueryEndpoint returns the address clients receive snapshots from.
+func (s *DigestServer) QueryEndpoint() string {
+	return s.queryListener.Addr().String()
+}
+
+// Snapshot returns the current QuantileSnapshot of the aggregated TDigest.
+func (s *DigestServer) Snapshot() *QuantileSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := &QuantileSnapshot{
+		Count:     s.digest.count,
+		Quantiles: []QuantileValue{},
+	}
+	if s.digest.count == 0 {
+		// Quantiles of an empty TDigest are NaN, which can't be encoded as JSON.
+		return snapshot
+	}
+	for _, q := range snapshotQuantiles {
+		snapshot.Quantiles = append(snapshot.Quantiles, QuantileValue{Q: q, Value: s.digest.quantile(q)})
+	}
+	return snapshot
+}
+
+// Shutdown stops accepting connections, closes all open connections, and waits
+// for their handlers to exit. Calls after the first do nothing.
+func (s *DigestServer) Shutdown() {
+	s.shutdownOnce.Do(func() {
+		close(s.done)
+		_ = s.addListener.Close()
+		_ = s.queryListener.Close()
+
+		s.connsMu.Lock()
+		for conn := range s.conns {
+			_ = conn.Close()
+		}
+		s.connsMu.Unlock()
+
+		s.wg.Wait()
+	})
+}
+
+// accept handles connections from l until l is closed.
+func (s *DigestServer) accept(l net.Listener, handle func(net.Conn)) {
+	defer s.wg.Done()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		s.connsMu.Lock()
+		select {
+		case <-s.done:
+			// We're shutting down, so don't start handling new connections.
+			s.connsMu.Unlock()
+			_ = conn.Close()
+			return
+		default:
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.connsMu.Unlock()
+
+		go func() {
+			defer s.wg.Done()
+			handle(conn)
+
+			s.connsMu.Lock()
+			delete(s.conns, conn)
+			s.connsMu.Unlock()
+			_ = conn.Close()
+		}()
+	}
+}
+
+// handleAdd adds observations from conn until it is closed.
+func (s *DigestServer) handleAdd(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		val, err := strconv.ParseFloat(strings.TrimSpace(scanner.Text()), 64)
+		if err != nil {
+			continue
+		}
+
+		// Like unparseable lines, NaN and infinities are skipped.
+		s.mu.Lock()
+		_ = s.digest.Add(val)
+		s.mu.Unlock()
+	}
+}
+
+// handleQuery sends snapshots to conn until it is closed or the server shuts
+// down.
+func (s *DigestServer) handleQuery(conn net.Conn) {
+	ticker := time.NewTicker(snapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Snapshot().WriteTo(conn); err != nil {
+			return
+		}
+
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
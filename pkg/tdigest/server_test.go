@@ -0,0 +1,103 @@
+package tdigest_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestDigestServer(t *testing.T) {
+	server, err := tdigest.StartServer("127.0.0.1:0", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Shutdown()
+
+	addConn, err := net.Dial("tcp", server.AddEndpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := bufio.NewWriter(addConn)
+	for i := 0; i < 1000; i++ {
+		_, _ = fmt.Fprintln(w, float64(i)/1000)
+	}
+	// Malformed lines are ignored.
+	_, _ = fmt.Fprintln(w, "not a number")
+	if err = w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err = addConn.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	queryConn, err := net.Dial("tcp", server.QueryEndpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer queryConn.Close()
+	if err = queryConn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The server may not have processed every value yet, so wait for a snapshot
+	// with all of them.
+	decoder := json.NewDecoder(queryConn)
+	snapshot := tdigest.QuantileSnapshot{}
+	for snapshot.Count < 1000 {
+		if err = decoder.Decode(&snapshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if snapshot.Count != 1000 {
+		t.Errorf("got count %v, want %v", snapshot.Count, 1000)
+	}
+	for _, qv := range snapshot.Quantiles {
+		if math.Abs(qv.Value-qv.Q) > 0.05 {
+			t.Errorf("got Quantile(%v) = %v, want approximately %v", qv.Q, qv.Value, qv.Q)
+		}
+	}
+}
+
+func TestDigestServer_Empty(t *testing.T) {
+	server, err := tdigest.StartServer("127.0.0.1:0", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Shutdown()
+
+	queryConn, err := net.Dial("tcp", server.QueryEndpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer queryConn.Close()
+
+	snapshot := tdigest.QuantileSnapshot{}
+	if err = json.NewDecoder(queryConn).Decode(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Count != 0 || len(snapshot.Quantiles) != 0 {
+		t.Errorf("got %+v, want empty snapshot", snapshot)
+	}
+}
+
+func TestDigestServer_Shutdown_Twice(t *testing.T) {
+	server, err := tdigest.StartServer("127.0.0.1:0", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.Shutdown()
+	server.Shutdown()
+
+	if conn, err := net.Dial("tcp", server.AddEndpoint()); err == nil {
+		conn.Close()
+		t.Error("got connection after Shutdown, want error")
+	}
+}
@@ -0,0 +1,44 @@
+package tdigest
+
+// SLO is a latency-style service level objective of the form
+// "the Quantile of observations is below Threshold", e.g. "P99 latency < 100ms".
+type SLO struct {
+	Name      string
+	Quantile  float64
+	Threshold float64
+}
+
+// SLOResult is the evaluation of an SLO against a TDigest.
+type SLOResult struct {
+	SLO
+
+	// Value is the estimated value of the SLO's quantile.
+	Value float64
+	// Met is whether Value is strictly below the SLO's Threshold.
+	Met bool
+	// Margin is the remaining headroom relative to the threshold,
+	// (Threshold - Value) / Threshold. It is negative for violated SLOs.
+	// If Threshold is 0, the headroom can't be relative, so Margin is the
+	// absolute headroom, -Value.
+	Margin float64
+}
+
+// SLOReport evaluates each of slos against the distribution in the TDigest.
+// Results are returned in the same order as slos.
+func (d *TDigest) SLOReport(slos []SLO) []SLOResult {
+	results := make([]SLOResult, len(slos))
+	for i, slo := range slos {
+		value := d.quantile(slo.Quantile)
+		margin := slo.Threshold - value
+		if slo.Threshold != 0 {
+			margin /= slo.Threshold
+		}
+		results[i] = SLOResult{
+			SLO:    slo,
+			Value:  value,
+			Met:    value < slo.Threshold,
+			Margin: margin,
+		}
+	}
+	return results
+}
@@ -0,0 +1,70 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_SLOReport(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.New(tdigest.WithCompression(100))
+	for i := 0; i < 100000; i++ {
+		// Latencies uniformly distributed between 0 and 100ms.
+		digest.Add(r.Float64() * 100)
+	}
+
+	slos := []tdigest.SLO{
+		{Name: "p99 < 100ms", Quantile: 0.99, Threshold: 100},
+		{Name: "p50 < 25ms", Quantile: 0.5, Threshold: 25},
+	}
+	results := digest.SLOReport(slos)
+	if len(results) != len(slos) {
+		t.Fatalf("got %d results, want %d", len(results), len(slos))
+	}
+
+	for i, result := range results {
+		if result.SLO != slos[i] {
+			t.Errorf("got SLO %+v, want %+v", result.SLO, slos[i])
+		}
+		if want := mustFloat(digest.Quantile(slos[i].Quantile)); result.Value != want {
+			t.Errorf("%s: got Value %v, want %v", result.Name, result.Value, want)
+		}
+		wantMargin := (result.Threshold - result.Value) / result.Threshold
+		if math.Abs(result.Margin-wantMargin) > 1e-12 {
+			t.Errorf("%s: got Margin %v, want %v", result.Name, result.Margin, wantMargin)
+		}
+	}
+
+	p99 := results[0]
+	if !p99.Met {
+		t.Errorf("%s: got Met = false for Value %v", p99.Name, p99.Value)
+	}
+	if p99.Margin <= 0 {
+		t.Errorf("%s: got Margin %v, want positive", p99.Name, p99.Margin)
+	}
+
+	p50 := results[1]
+	if p50.Met {
+		t.Errorf("%s: got Met = true for Value %v", p50.Name, p50.Value)
+	}
+	// p50 is approximately 50ms, so the SLO is missed by about 100%.
+	if p50.Margin > -0.9 || p50.Margin < -1.1 {
+		t.Errorf("%s: got Margin %v, want approximately -1", p50.Name, p50.Margin)
+	}
+}
+
+func TestTDigest_SLOReport_ZeroThreshold(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(100))
+	for _, val := range []float64{-3, -2, -1} {
+		digest.Add(val)
+	}
+
+	// With a zero threshold, Margin is the absolute headroom.
+	results := digest.SLOReport([]tdigest.SLO{{Name: "p50 < 0", Quantile: 0.5, Threshold: 0}})
+	if got := results[0]; !got.Met || got.Margin != -got.Value {
+		t.Errorf("got Met = %v and Margin %v for Value %v, want true and %v", got.Met, got.Margin, got.Value, -got.Value)
+	}
+}
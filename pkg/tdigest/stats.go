@@ -0,0 +1,240 @@
+package tdigest
+
+import (
+	"fmt"
+	"math"
+)
+
+// Median returns the estimated median, or ErrEmptyDigest if the TDigest is
+// empty.
+func (d *TDigest) Median() (float64, error) {
+	return d.Quantile(0.5)
+}
+
+// IQR returns the estimated interquartile range, Quantile(0.75) minus
+// Quantile(0.25), or ErrEmptyDigest if the TDigest is empty.
+func (d *TDigest) IQR() (float64, error) {
+	if d.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+	return d.quantile(0.75) - d.quantile(0.25), nil
+}
+
+// TrimmedMean returns the estimated mean of the observations between quantiles
+// lower and upper, or ErrEmptyDigest if the TDigest is empty. It returns NaN
+// and an error unless 0 <= lower < upper <= 1. TrimmedMean(0, 1) is Mean.
+//
+// Centroids entirely between the quantiles contribute their exact means.
+// Centroids straddling lower or upper contribute the part of their
+// observations between the quantiles, estimated by interpolating as Quantile
+// does.
+func (d *TDigest) TrimmedMean(lower, upper float64) (float64, error) {
+	if !(0 <= lower && lower < upper && upper <= 1) {
+		return math.NaN(), fmt.Errorf("tdigest: trimmed mean needs 0 <= lower < upper <= 1, got %v and %v", lower, upper)
+	}
+	switch d.nCentroids {
+	case 0:
+		return math.NaN(), ErrEmptyDigest
+	case 1:
+		return d.centroids[0].mean, nil
+	}
+
+	lo, hi := lower*d.count, upper*d.count
+	return d.sumBetween(lo, hi) / (hi - lo), nil
+}
+
+// ConditionalMean returns the estimated mean of the observations between a
+// and b inclusive, E[X | a <= X <= b], or ErrEmptyDigest if the TDigest is
+// empty. It returns NaN and an error if a > b, and NaN if no observations are
+// estimated to be between a and b.
+//
+// ConditionalMean(a, b) * ConditionalCount(a, b) estimates the sum of the
+// observations between a and b. If a <= Min and b >= Max, it is Mean() *
+// Count(), the running sum of all observations.
+//
+// As in TrimmedMean, centroids straddling a or b contribute the part of their
+// observations between them, estimated by interpolating as Quantile does.
+func (d *TDigest) ConditionalMean(a, b float64) (float64, error) {
+	if !(a <= b) {
+		return math.NaN(), fmt.Errorf("tdigest: conditional mean needs a <= b, got %v and %v", a, b)
+	}
+	switch d.nCentroids {
+	case 0:
+		return math.NaN(), ErrEmptyDigest
+	case 1:
+		if a <= d.centroids[0].mean && d.centroids[0].mean <= b {
+			return d.centroids[0].mean, nil
+		}
+		return math.NaN(), nil
+	}
+
+	lo, hi := d.cdf(a)*d.count, d.cdf(b)*d.count
+	if lo == 0 && hi == d.count {
+		return d.mean(), nil
+	}
+	return d.sumBetween(lo, hi) / (hi - lo), nil
+}
+
+// sumBetween returns the estimated sum of the observations with ranks between
+// lo and hi. The TDigest must have at least 2 centroids.
+func (d *TDigest) sumBetween(lo, hi float64) float64 {
+	var sum, total float64
+	for i, c := range d.centroids {
+		start, end := total, total+c.count
+		total = end
+		if end <= lo {
+			continue
+		}
+		if start >= hi {
+			break
+		}
+		if lo <= start && end <= hi {
+			sum += c.mean * c.count
+			continue
+		}
+
+		// Estimate the mean of the part of the centroid between lo and hi
+		// by the value at the middle of that part.
+		from, to := math.Max(start, lo), math.Min(end, hi)
+		sum += (to - from) * d.valueWithin(i, start, (from+to)/2)
+	}
+	return sum
+}
+
+// ExpectedShortfall returns the estimated mean of the observations above
+// Quantile(alpha), also called the conditional value at risk, or
+// ErrEmptyDigest if the TDigest is empty. It returns NaN and an error unless
+// 0 <= alpha <= 1. ExpectedShortfall(1) is Max.
+//
+// As in TrimmedMean, the centroid straddling Quantile(alpha) contributes only
+// the part of its observations above it.
+func (d *TDigest) ExpectedShortfall(alpha float64) (float64, error) {
+	if !(0 <= alpha && alpha <= 1) {
+		return math.NaN(), fmt.Errorf("tdigest: expected shortfall needs 0 <= alpha <= 1, got %v", alpha)
+	}
+	switch {
+	case d.nCentroids == 0:
+		return math.NaN(), ErrEmptyDigest
+	case d.nCentroids == 1:
+		return d.centroids[0].mean, nil
+	case alpha == 1:
+		return d.max, nil
+	}
+
+	threshold := alpha * d.count
+	var sum, after float64
+	for i := d.nCentroids - 1; i >= 0; i-- {
+		c := d.centroids[i]
+		start := d.count - after - c.count
+		if start >= threshold {
+			sum += c.mean * c.count
+			after += c.count
+			continue
+		}
+
+		// Estimate the mean of the part of the centroid above the threshold
+		// by the value at the middle of that part.
+		end := start + c.count
+		sum += (end - threshold) * d.valueWithin(i, start, (threshold+end)/2)
+		break
+	}
+	return sum / (d.count - threshold), nil
+}
+
+// Skewness returns the estimated skewness, the third standardized moment of
+// the observations, or ErrEmptyDigest if the TDigest is empty. It is NaN for a
+// single centroid, whose observations have no estimated spread.
+//
+// Like Variance, this treats the observations of each centroid as all at its
+// mean, so it is an approximation which ignores the spread within centroids.
+func (d *TDigest) Skewness() (float64, error) {
+	return d.standardizedMoment(3)
+}
+
+// Kurtosis returns the estimated kurtosis, the fourth standardized moment of
+// the observations, or ErrEmptyDigest if the TDigest is empty. It is 3 for a
+// normal distribution, so subtract 3 for the excess kurtosis. It is NaN for a
+// single centroid, whose observations have no estimated spread.
+//
+// As for Skewness, the spread within centroids is ignored.
+func (d *TDigest) Kurtosis() (float64, error) {
+	return d.standardizedMoment(4)
+}
+
+// standardizedMoment returns the kth central moment of the centroids divided
+// by the kth power of their standard deviation.
+func (d *TDigest) standardizedMoment(k float64) (float64, error) {
+	switch d.nCentroids {
+	case 0:
+		return math.NaN(), ErrEmptyDigest
+	case 1:
+		return math.NaN(), nil
+	}
+
+	mean := d.mean()
+	var sum float64
+	for _, c := range d.centroids {
+		sum += c.count * math.Pow(c.mean-mean, k)
+	}
+	return sum / d.count / math.Pow(d.variance(), k/2), nil
+}
+
+// Entropy returns the Shannon entropy in nats of the centroids' weights,
+// -sum(p*log(p)) where p is each centroid's fraction of the count, or
+// ErrEmptyDigest if the TDigest is empty. A single centroid has entropy 0.
+//
+// This is the entropy of the centroids, not of the observations: observations
+// merged into one centroid are indistinguishable, so for spread out
+// distributions it underestimates the entropy of the observations.
+func (d *TDigest) Entropy() (float64, error) {
+	if d.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+
+	var entropy float64
+	for _, c := range d.centroids {
+		p := c.count / d.count
+		entropy -= p * math.Log(p)
+	}
+	return entropy, nil
+}
+
+// Gini returns the estimated Gini coefficient, the mean absolute difference
+// between pairs of observations divided by twice their mean, or
+// ErrEmptyDigest if the TDigest is empty. It returns NaN if there are fewer
+// than 2 observations. The coefficient is only meaningful for non-negative
+// observations, where it ranges from 0 when all are equal towards 1 when one
+// observation holds the entire total.
+//
+// As with Entropy, observations merged into one centroid are treated as
+// equal, so spread out distributions have a slightly smaller estimate.
+func (d *TDigest) Gini() (float64, error) {
+	if d.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+	if d.count < 2 {
+		return math.NaN(), nil
+	}
+
+	// Centroids are sorted, so the sum over pairs of
+	// count_i * count_j * |mean_i - mean_j| only needs the count and sum of
+	// the centroids below each one.
+	var pairs, countBelow, sumBelow float64
+	for _, c := range d.centroids {
+		pairs += c.count * (c.mean*countBelow - sumBelow)
+		countBelow += c.count
+		sumBelow += c.count * c.mean
+	}
+	return pairs / (d.count * d.count * d.mean()), nil
+}
+
+// valueWithin returns the value at rescaled quantile q, which is within the
+// centroid at idx, given the total count before it. As in
+// interpolatedQuantile, values are interpolated between centroid midpoints.
+func (d *TDigest) valueWithin(idx int, before, q float64) float64 {
+	if idx < d.nCentroids-1 && q > before+d.centroids[idx].count/2 {
+		// The next centroid's midpoint is the first at least q.
+		return d.clamp(d.interpolateAt(q, idx+1, before+d.centroids[idx].count))
+	}
+	return d.clamp(d.interpolateAt(q, idx, before))
+}
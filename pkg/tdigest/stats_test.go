@@ -0,0 +1,320 @@
+package tdigest_test
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_Median_IQR(t *testing.T) {
+	digest := newUniformRange(20, 10000, 0, 1, 1)
+
+	if got, want := mustFloat(digest.Median()), mustFloat(digest.Quantile(0.5)); got != want {
+		t.Errorf("got Median() = %v, want %v", got, want)
+	}
+	want := mustFloat(digest.Quantile(0.75)) - mustFloat(digest.Quantile(0.25))
+	if got := mustFloat(digest.IQR()); got != want {
+		t.Errorf("got IQR() = %v, want %v", got, want)
+	}
+	if got := mustFloat(digest.IQR()); math.Abs(got-0.5) > 0.05 {
+		t.Errorf("got IQR() = %v, want about 0.5", got)
+	}
+}
+
+func TestTDigest_TrimmedMean(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.New(tdigest.WithCompression(20))
+	vals := make([]float64, 100000)
+	for i := range vals {
+		vals[i] = r.ExpFloat64()
+		digest.Add(vals[i])
+	}
+	sort.Float64s(vals)
+
+	// The whole range is exactly the mean.
+	if got, want := mustFloat(digest.TrimmedMean(0, 1)), mustFloat(digest.Mean()); math.Abs(got-want) > 1e-9 {
+		t.Errorf("got TrimmedMean(0, 1) = %v, want Mean() = %v", got, want)
+	}
+
+	tcs := []struct {
+		lower, upper float64
+	}{{0, 0.5}, {0.05, 0.95}, {0.25, 0.75}, {0.9, 1}, {0.49, 0.51}}
+	for _, tc := range tcs {
+		lo, hi := int(tc.lower*float64(len(vals))), int(tc.upper*float64(len(vals)))
+		var sum float64
+		for _, v := range vals[lo:hi] {
+			sum += v
+		}
+		want := sum / float64(hi-lo)
+
+		got := mustFloat(digest.TrimmedMean(tc.lower, tc.upper))
+		if math.Abs(got-want) > 0.01*want {
+			t.Errorf("got TrimmedMean(%v, %v) = %v, want %v", tc.lower, tc.upper, got, want)
+		}
+	}
+}
+
+func TestTDigest_TrimmedMean_Errors(t *testing.T) {
+	digest := newUniformRange(20, 1000, 0, 1, 1)
+	tcs := []struct {
+		name         string
+		lower, upper float64
+	}{{
+		name:  "equal",
+		lower: 0.5, upper: 0.5,
+	}, {
+		name:  "reversed",
+		lower: 0.9, upper: 0.1,
+	}, {
+		name:  "negative lower",
+		lower: -0.1, upper: 0.5,
+	}, {
+		name:  "upper above 1",
+		lower: 0.5, upper: 1.1,
+	}, {
+		name:  "NaN",
+		lower: math.NaN(), upper: 0.5,
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := digest.TrimmedMean(tc.lower, tc.upper)
+			if err == nil {
+				t.Errorf("got TrimmedMean(%v, %v) = %v, want error", tc.lower, tc.upper, got)
+			}
+			if !math.IsNaN(got) {
+				t.Errorf("got TrimmedMean(%v, %v) = %v, want NaN", tc.lower, tc.upper, got)
+			}
+		})
+	}
+
+	empty := tdigest.New()
+	for name, f := range map[string]func() (float64, error){
+		"Median":      empty.Median,
+		"IQR":         empty.IQR,
+		"TrimmedMean": func() (float64, error) { return empty.TrimmedMean(0.1, 0.9) },
+	} {
+		if got, err := f(); !errors.Is(err, tdigest.ErrEmptyDigest) || !math.IsNaN(got) {
+			t.Errorf("got %s() = %v, %v on empty TDigest, want NaN, %v", name, got, err, tdigest.ErrEmptyDigest)
+		}
+	}
+}
+
+func TestTDigest_ConditionalMean(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.New(tdigest.WithCompression(20))
+	vals := make([]float64, 100000)
+	for i := range vals {
+		vals[i] = r.ExpFloat64()
+		digest.Add(vals[i])
+	}
+	sort.Float64s(vals)
+
+	// The whole range is exactly the mean.
+	if got, want := mustFloat(digest.ConditionalMean(-1, 100)), mustFloat(digest.Mean()); got != want {
+		t.Errorf("got ConditionalMean(-1, 100) = %v, want Mean() = %v", got, want)
+	}
+
+	tcs := []struct {
+		a, b float64
+	}{{0, 1}, {0.5, 1.5}, {1, 2}, {2, 5}, {0.1, 0.2}}
+	for _, tc := range tcs {
+		var sum, count float64
+		for _, v := range vals {
+			if tc.a <= v && v <= tc.b {
+				sum += v
+				count++
+			}
+		}
+
+		got := mustFloat(digest.ConditionalMean(tc.a, tc.b))
+		if want := sum / count; math.Abs(got-want) > 0.01*want {
+			t.Errorf("got ConditionalMean(%v, %v) = %v, want %v", tc.a, tc.b, got, want)
+		}
+		gotCount := digest.ConditionalCount(tc.a, tc.b)
+		if math.Abs(gotCount-count) > 0.01*count {
+			t.Errorf("got ConditionalCount(%v, %v) = %v, want %v", tc.a, tc.b, gotCount, count)
+		}
+		if gotSum := got * gotCount; math.Abs(gotSum-sum) > 0.02*sum {
+			t.Errorf("got ConditionalMean * ConditionalCount = %v for [%v, %v], want sum %v", gotSum, tc.a, tc.b, sum)
+		}
+	}
+
+	// No observations are above the maximum.
+	if got := mustFloat(digest.ConditionalMean(100, 200)); !math.IsNaN(got) {
+		t.Errorf("got ConditionalMean(100, 200) = %v, want NaN", got)
+	}
+}
+
+func TestTDigest_ConditionalMean_Errors(t *testing.T) {
+	digest := newUniformRange(20, 1000, 0, 1, 1)
+	if got, err := digest.ConditionalMean(0.9, 0.1); err == nil || !math.IsNaN(got) {
+		t.Errorf("got ConditionalMean(0.9, 0.1) = %v, %v, want NaN and an error", got, err)
+	}
+	if got := digest.ConditionalCount(0.9, 0.1); !math.IsNaN(got) {
+		t.Errorf("got ConditionalCount(0.9, 0.1) = %v, want NaN", got)
+	}
+
+	empty := tdigest.New()
+	if got, err := empty.ConditionalMean(0, 1); !errors.Is(err, tdigest.ErrEmptyDigest) || !math.IsNaN(got) {
+		t.Errorf("got ConditionalMean(0, 1) = %v, %v on empty TDigest, want NaN, %v", got, err, tdigest.ErrEmptyDigest)
+	}
+	if got := empty.ConditionalCount(0, 1); got != 0 {
+		t.Errorf("got ConditionalCount(0, 1) = %v on empty TDigest, want 0", got)
+	}
+}
+
+func TestTDigest_ExpectedShortfall(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.New(tdigest.WithCompression(20))
+	vals := make([]float64, 100000)
+	for i := range vals {
+		vals[i] = r.ExpFloat64()
+		digest.Add(vals[i])
+	}
+	sort.Float64s(vals)
+
+	for _, alpha := range []float64{0, 0.5, 0.9, 0.95, 0.99, 0.999} {
+		var sum float64
+		tail := vals[int(alpha*float64(len(vals))):]
+		for _, v := range tail {
+			sum += v
+		}
+		want := sum / float64(len(tail))
+
+		got := mustFloat(digest.ExpectedShortfall(alpha))
+		if math.Abs(got-want) > 0.01*want {
+			t.Errorf("got ExpectedShortfall(%v) = %v, want %v", alpha, got, want)
+		}
+	}
+
+	if got, want := mustFloat(digest.ExpectedShortfall(1)), vals[len(vals)-1]; got != want {
+		t.Errorf("got ExpectedShortfall(1) = %v, want %v", got, want)
+	}
+	for _, alpha := range []float64{-0.1, 1.1, math.NaN()} {
+		if got, err := digest.ExpectedShortfall(alpha); err == nil || !math.IsNaN(got) {
+			t.Errorf("got ExpectedShortfall(%v) = %v, %v, want NaN and an error", alpha, got, err)
+		}
+	}
+	if got, err := tdigest.New().ExpectedShortfall(0.9); !errors.Is(err, tdigest.ErrEmptyDigest) || !math.IsNaN(got) {
+		t.Errorf("got ExpectedShortfall(0.9) = %v, %v on empty TDigest, want NaN, %v", got, err, tdigest.ErrEmptyDigest)
+	}
+}
+
+func TestTDigest_Entropy(t *testing.T) {
+	for _, k := range []int{1, 2, 10, 100} {
+		centroids := make([]tdigest.CentroidData, k)
+		for i := range centroids {
+			centroids[i] = tdigest.CentroidData{Mean: float64(i), Count: 5}
+		}
+		digest, err := tdigest.FromCentroids(20, centroids)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := mustFloat(digest.Entropy()), math.Log(float64(k)); math.Abs(got-want) > 1e-9 {
+			t.Errorf("got Entropy() = %v for %d equal centroids, want %v", got, k, want)
+		}
+	}
+
+	// Unequal weights have less entropy than equal ones.
+	digest := newUniformRange(20, 10000, 0, 1, 1)
+	if got, most := mustFloat(digest.Entropy()), math.Log(float64(digest.CentroidCount())); got <= 0 || got >= most {
+		t.Errorf("got Entropy() = %v, want between 0 and %v", got, most)
+	}
+
+	if got, err := tdigest.New().Entropy(); !errors.Is(err, tdigest.ErrEmptyDigest) || !math.IsNaN(got) {
+		t.Errorf("got Entropy() = %v, %v on empty TDigest, want NaN, %v", got, err, tdigest.ErrEmptyDigest)
+	}
+}
+
+func TestTDigest_Gini(t *testing.T) {
+	tcs := []struct {
+		name      string
+		centroids []tdigest.CentroidData
+		want      float64
+	}{{
+		name:      "equal",
+		centroids: []tdigest.CentroidData{{Mean: 3, Count: 1000}},
+		want:      0,
+	}, {
+		// One observation of 1000 and 999 of 0 has a Gini coefficient of
+		// 999/1000.
+		name:      "dominant",
+		centroids: []tdigest.CentroidData{{Mean: 0, Count: 999}, {Mean: 1000, Count: 1}},
+		want:      0.999,
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			digest, err := tdigest.FromCentroids(20, tc.centroids)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := mustFloat(digest.Gini()); math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("got Gini() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	// Uniform [0, 1] has a Gini coefficient of 1/3.
+	if got := mustFloat(newUniformRange(20, 100000, 0, 1, 1).Gini()); math.Abs(got-1.0/3) > 0.01 {
+		t.Errorf("got Gini() = %v for uniform distribution, want 1/3 +/- 0.01", got)
+	}
+
+	single := tdigest.New()
+	single.Add(1)
+	if got := mustFloat(single.Gini()); !math.IsNaN(got) {
+		t.Errorf("got Gini() = %v for one observation, want NaN", got)
+	}
+	if got, err := tdigest.New().Gini(); !errors.Is(err, tdigest.ErrEmptyDigest) || !math.IsNaN(got) {
+		t.Errorf("got Gini() = %v, %v on empty TDigest, want NaN, %v", got, err, tdigest.ErrEmptyDigest)
+	}
+}
+
+func TestTDigest_Skewness_Kurtosis(t *testing.T) {
+	tcs := []struct {
+		name               string
+		next               func(r *rand.Rand) float64
+		skewness, kurtosis float64
+	}{{
+		name:     "normal",
+		next:     func(r *rand.Rand) float64 { return r.NormFloat64() },
+		skewness: 0,
+		kurtosis: 3,
+	}, {
+		name:     "exponential",
+		next:     func(r *rand.Rand) float64 { return r.ExpFloat64() },
+		skewness: 2,
+		kurtosis: 9,
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			digest := tdigest.New(tdigest.WithCompression(20))
+			for i := 0; i < 100000; i++ {
+				digest.Add(tc.next(r))
+			}
+
+			if got := mustFloat(digest.Skewness()); math.Abs(got-tc.skewness) > 0.1 {
+				t.Errorf("got Skewness() = %v, want %v +/- 0.1", got, tc.skewness)
+			}
+			if got := mustFloat(digest.Kurtosis()); math.Abs(got-tc.kurtosis) > 0.1*tc.kurtosis {
+				t.Errorf("got Kurtosis() = %v, want %v +/- 10%%", got, tc.kurtosis)
+			}
+		})
+	}
+
+	single := tdigest.New()
+	single.Add(1)
+	if got, err := single.Skewness(); err != nil || !math.IsNaN(got) {
+		t.Errorf("got Skewness() = %v, %v for one centroid, want NaN, nil", got, err)
+	}
+	if got, err := tdigest.New().Kurtosis(); !errors.Is(err, tdigest.ErrEmptyDigest) || !math.IsNaN(got) {
+		t.Errorf("got Kurtosis() = %v, %v on empty TDigest, want NaN, %v", got, err, tdigest.ErrEmptyDigest)
+	}
+}
@@ -0,0 +1,170 @@
+package tdigest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// streamChunkSize is the number of centroids WriteTo and ReadFrom encode or
+// decode at a time.
+const streamChunkSize = 256
+
+var (
+	_ io.WriterTo   = (*TDigest)(nil)
+	_ io.ReaderFrom = (*TDigest)(nil)
+)
+
+// binaryHeader is the header of the binary format after the version byte,
+// laid out for binary.Read and binary.Write.
+type binaryHeader struct {
+	Compression  float64
+	Count        float64
+	Sum          float64
+	NumCentroids uint64
+}
+
+// binaryHeaderV1 is the header of version 1 of the binary format, which has no
+// sum.
+type binaryHeaderV1 struct {
+	Compression  float64
+	Count        float64
+	NumCentroids uint64
+}
+
+// WriteTo writes the TDigest to w in the format of MarshalBinary, without
+// encoding it all in memory first. It returns the number of bytes written.
+func (d *TDigest) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	header := binaryHeader{
+		Compression:  d.Compression(),
+		Count:        d.count,
+		Sum:          d.sum,
+		NumCentroids: uint64(d.nCentroids),
+	}
+	if _, err := cw.Write([]byte{binaryVersion}); err != nil {
+		return cw.n, err
+	}
+	if err := binary.Write(cw, binary.LittleEndian, header); err != nil {
+		return cw.n, err
+	}
+
+	chunk := make([]float64, 0, 2*streamChunkSize)
+	for i, c := range d.centroids {
+		chunk = append(chunk, c.mean, c.count)
+		if len(chunk) < cap(chunk) && i < d.nCentroids-1 {
+			continue
+		}
+		if err := binary.Write(cw, binary.LittleEndian, chunk); err != nil {
+			return cw.n, err
+		}
+		chunk = chunk[:0]
+	}
+	return cw.n, nil
+}
+
+// ReadFrom replaces the TDigest with one read from r in the format of
+// MarshalBinary, including version 1. It returns the number of bytes read.
+//
+// Centroids are validated as they are read, so malformed input is rejected
+// without reading the rest of it. If reading fails, the TDigest is unchanged.
+func (d *TDigest) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+	var version uint8
+	if err := binary.Read(cr, binary.LittleEndian, &version); err != nil {
+		return cr.n, streamReadError(err)
+	}
+	var header binaryHeader
+	switch version {
+	case binaryVersion:
+		if err := binary.Read(cr, binary.LittleEndian, &header); err != nil {
+			return cr.n, streamReadError(err)
+		}
+	case 1:
+		var v1 binaryHeaderV1
+		if err := binary.Read(cr, binary.LittleEndian, &v1); err != nil {
+			return cr.n, streamReadError(err)
+		}
+		header = binaryHeader{Compression: v1.Compression, Count: v1.Count, NumCentroids: v1.NumCentroids}
+	default:
+		return cr.n, fmt.Errorf("tdigest: unknown TDigest version %d", version)
+	}
+
+	if err := validateCompression(header.Compression); err != nil {
+		return cr.n, err
+	}
+
+	// Don't trust NumCentroids for the allocation, since the input may be
+	// truncated or malformed.
+	centroids := make([]centroid, 0, streamChunkSize)
+	chunk := make([]float64, 2*streamChunkSize)
+	var total float64
+	for remaining := header.NumCentroids; remaining > 0; {
+		k := uint64(streamChunkSize)
+		if remaining < k {
+			k = remaining
+		}
+		if err := binary.Read(cr, binary.LittleEndian, chunk[:2*k]); err != nil {
+			return cr.n, streamReadError(err)
+		}
+
+		for j := uint64(0); j < k; j++ {
+			c := centroid{mean: chunk[2*j], count: chunk[2*j+1]}
+			var prev *centroid
+			if len(centroids) > 0 {
+				prev = &centroids[len(centroids)-1]
+			}
+			if err := validateCentroid(len(centroids), &c, prev); err != nil {
+				return cr.n, err
+			}
+			centroids = append(centroids, c)
+			total += c.count
+		}
+		remaining -= k
+	}
+	if err := validateCount(header.Count, total); err != nil {
+		return cr.n, err
+	}
+
+	d.reset()
+	d.compression = header.Compression
+	d.setCentroids(centroids)
+	if version != 1 {
+		d.sum = header.Sum
+	}
+	return cr.n, nil
+}
+
+// streamReadError converts errors from reading too few bytes into
+// errTruncatedBinary, and returns other errors unchanged.
+func streamReadError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return errTruncatedBinary
+	}
+	return err
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
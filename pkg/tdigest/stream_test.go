@@ -0,0 +1,155 @@
+package tdigest_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_WriteTo(t *testing.T) {
+	for _, digest := range []*tdigest.TDigest{
+		tdigest.New(tdigest.WithCompression(20)),
+		newUniformRange(20, 1000, 0, 1, 1),
+		newUniformRange(1, 10000, 0, 1, 1),
+	} {
+		want := digest.Serialize()
+
+		var buf bytes.Buffer
+		n, err := digest.WriteTo(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != int64(len(want)) {
+			t.Errorf("got WriteTo() = %d bytes, want %d", n, len(want))
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("got WriteTo() different from MarshalBinary()")
+		}
+	}
+}
+
+func TestTDigest_ReadFrom_Pipe(t *testing.T) {
+	digest := newUniformRange(1, 10000, 0, 1, 1)
+
+	pr, pw := io.Pipe()
+	written := make(chan int64, 1)
+	go func() {
+		n, err := digest.WriteTo(pw)
+		pw.CloseWithError(err)
+		written <- n
+	}()
+
+	got := tdigest.New(tdigest.WithCompression(100))
+	n, err := got.ReadFrom(pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := <-written; n != want {
+		t.Errorf("got ReadFrom() = %d bytes, want %d", n, want)
+	}
+
+	if got.Count() != digest.Count() {
+		t.Errorf("got Count() = %v, want %v", got.Count(), digest.Count())
+	}
+	for _, q := range []float64{0.01, 0.5, 0.99} {
+		want := mustFloat(digest.Quantile(q))
+		if got := mustFloat(got.Quantile(q)); got != want {
+			t.Errorf("got Quantile(%v) = %v, want %v", q, got, want)
+		}
+	}
+}
+
+var errStream = errors.New("stream failed")
+
+// limitedWriter accepts limit bytes, then fails.
+type limitedWriter struct {
+	limit int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errStream
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errStream
+}
+
+func TestTDigest_WriteTo_Fails(t *testing.T) {
+	digest := newUniformRange(1, 10000, 0, 1, 1)
+	size := len(digest.Serialize())
+
+	for _, limit := range []int{0, 10, 1000, size - 1} {
+		n, err := digest.WriteTo(&limitedWriter{limit: limit})
+		if err != errStream {
+			t.Errorf("got error %v writing %d of %d bytes, want %v", err, limit, size, errStream)
+		}
+		if n != int64(limit) {
+			t.Errorf("got WriteTo() = %d bytes, want %d", n, limit)
+		}
+	}
+}
+
+func TestTDigest_ReadFrom_Fails(t *testing.T) {
+	b := newUniformRange(1, 10000, 0, 1, 1).Serialize()
+
+	for _, limit := range []int{0, 10, 1000, len(b) - 1} {
+		digest := newUniformRange(20, 100, 0, 1, 1)
+		r := io.MultiReader(bytes.NewReader(b[:limit]), failingReader{})
+		n, err := digest.ReadFrom(r)
+		if err != errStream {
+			t.Errorf("got error %v reading %d of %d bytes, want %v", err, limit, len(b), errStream)
+		}
+		if n != int64(limit) {
+			t.Errorf("got ReadFrom() = %d bytes, want %d", n, limit)
+		}
+		if digest.Count() != 100 {
+			t.Errorf("got Count() = %v after failed ReadFrom, want unchanged 100", digest.Count())
+		}
+
+		if _, err = digest.ReadFrom(bytes.NewReader(b[:limit])); err == nil {
+			t.Errorf("got no error reading %d of %d bytes", limit, len(b))
+		}
+	}
+}
+
+func TestTDigest_ReadFrom_Malformed(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(1))
+	digest.Add(1)
+	digest.Add(2)
+	b := digest.Serialize()
+
+	// Swap the two centroids so their means are out of order.
+	swapped := append([]byte{}, b[:33]...)
+	swapped = append(swapped, b[49:65]...)
+	swapped = append(swapped, b[33:49]...)
+
+	tcs := []struct {
+		name string
+		b    []byte
+	}{{
+		name: "unknown version",
+		b:    append([]byte{3}, b[1:]...),
+	}, {
+		name: "unsorted centroids",
+		b:    swapped,
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tdigest.New(tdigest.WithCompression(20)).ReadFrom(bytes.NewReader(tc.b)); err == nil {
+				t.Error("got no error")
+			}
+		})
+	}
+}
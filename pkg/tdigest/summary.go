@@ -0,0 +1,122 @@
+package tdigest
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"text/tabwriter"
+)
+
+// HistogramSummary is a fixed set of statistics of a TDigest, for logging or
+// exporting a distribution without its centroids.
+type HistogramSummary struct {
+	Count float64 `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Mean  float64 `json:"mean"`
+
+	P50  float64 `json:"p50"`
+	P75  float64 `json:"p75"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	P999 float64 `json:"p999"`
+}
+
+// Summary computes the HistogramSummary of d. The summary of an empty TDigest
+// is all zeros, so it can always be marshalled to JSON.
+func Summary(d *TDigest) HistogramSummary {
+	if d.nCentroids == 0 {
+		return HistogramSummary{}
+	}
+
+	return HistogramSummary{
+		Count: d.count,
+		Min:   d.min,
+		Max:   d.max,
+		Mean:  d.mean(),
+		P50:   d.quantile(0.5),
+		P75:   d.quantile(0.75),
+		P90:   d.quantile(0.9),
+		P95:   d.quantile(0.95),
+		P99:   d.quantile(0.99),
+		P999:  d.quantile(0.999),
+	}
+}
+
+// String formats the summary on one line, e.g.
+// "count=5000 min=0.1 mean=1.3 p50=1.2 ... p999=6.1 max=7.4".
+func (s HistogramSummary) String() string {
+	return fmt.Sprintf("count=%d min=%g mean=%g p50=%g p75=%g p90=%g p95=%g p99=%g p999=%g max=%g",
+		int(s.Count), s.Min, s.Mean, s.P50, s.P75, s.P90, s.P95, s.P99, s.P999, s.Max)
+}
+
+// SummaryStats are the statistics of a TDigest most often logged or returned
+// by APIs, from Summarize. Unlike HistogramSummary, whose fields are a fixed
+// export format, it includes the standard deviation and is formatted as a
+// table.
+type SummaryStats struct {
+	Count  float64 `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+
+	P25 float64 `json:"p25"`
+	P50 float64 `json:"p50"`
+	P75 float64 `json:"p75"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+// summarizeQuantiles are the quantiles of SummaryStats.
+var summarizeQuantiles = []float64{0.25, 0.5, 0.75, 0.9, 0.95, 0.99}
+
+// Summarize returns the SummaryStats of the TDigest. The quantiles are
+// computed together by Quantiles, in one pass over the centroids. As for
+// Summary, the stats of an empty TDigest are all zeros.
+func (d *TDigest) Summarize() SummaryStats {
+	if d.nCentroids == 0 {
+		return SummaryStats{}
+	}
+
+	// The TDigest isn't empty, so Quantiles can't fail.
+	ps, _ := d.Quantiles(summarizeQuantiles)
+	return SummaryStats{
+		Count:  d.count,
+		Min:    d.min,
+		Max:    d.max,
+		Mean:   d.mean(),
+		StdDev: math.Sqrt(d.variance()),
+		P25:    ps[0],
+		P50:    ps[1],
+		P75:    ps[2],
+		P90:    ps[3],
+		P95:    ps[4],
+		P99:    ps[5],
+	}
+}
+
+// String formats the stats as a table with one statistic per line, e.g.
+//
+//	count   5000
+//	min     0.1
+//	max     7.4
+//	...
+//	p99     6.1
+func (s SummaryStats) String() string {
+	sb := strings.Builder{}
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	for _, row := range []struct {
+		name string
+		val  float64
+	}{
+		{"count", s.Count}, {"min", s.Min}, {"max", s.Max}, {"mean", s.Mean}, {"stddev", s.StdDev},
+		{"p25", s.P25}, {"p50", s.P50}, {"p75", s.P75}, {"p90", s.P90}, {"p95", s.P95}, {"p99", s.P99},
+	} {
+		fmt.Fprintf(w, "%s\t%g\n", row.name, row.val)
+	}
+	w.Flush()
+	return sb.String()
+}
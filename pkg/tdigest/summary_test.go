@@ -0,0 +1,140 @@
+package tdigest_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestSummary_JSON(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(1))
+	for _, v := range []float64{1, 2, 3, 4} {
+		digest.Add(v)
+	}
+	summary := tdigest.Summary(digest)
+
+	b, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]float64
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]float64{
+		"count": 4,
+		"min":   1,
+		"max":   4,
+		"mean":  2.5,
+		"p50":   mustFloat(digest.Quantile(0.5)),
+		"p75":   mustFloat(digest.Quantile(0.75)),
+		"p90":   mustFloat(digest.Quantile(0.9)),
+		"p95":   mustFloat(digest.Quantile(0.95)),
+		"p99":   mustFloat(digest.Quantile(0.99)),
+		"p999":  mustFloat(digest.Quantile(0.999)),
+	}
+	if len(got) != len(want) {
+		t.Errorf("got keys %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("got %s = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestSummary_Empty(t *testing.T) {
+	summary := tdigest.Summary(tdigest.New(tdigest.WithCompression(100)))
+	if summary != (tdigest.HistogramSummary{}) {
+		t.Errorf("got %+v, want zero summary", summary)
+	}
+	if _, err := json.Marshal(summary); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHistogramSummary_String(t *testing.T) {
+	summary := tdigest.HistogramSummary{
+		Count: 5000, Min: 0.1, Max: 7.4, Mean: 1.3,
+		P50: 1.2, P75: 2, P90: 3, P95: 3.5, P99: 4.5, P999: 6.1,
+	}
+
+	want := "count=5000 min=0.1 mean=1.3 p50=1.2 p75=2 p90=3 p95=3.5 p99=4.5 p999=6.1 max=7.4"
+	if got := summary.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTDigest_Summarize(t *testing.T) {
+	digest := newUniformRange(20, 100000, 0, 1, 1)
+	got := digest.Summarize()
+
+	// Uniform [0, 1] has mean 1/2, standard deviation 1/sqrt(12), and
+	// quantile q at q.
+	tcs := []struct {
+		name      string
+		got, want float64
+	}{
+		{"Count", got.Count, 100000},
+		{"Min", got.Min, 0},
+		{"Max", got.Max, 1},
+		{"Mean", got.Mean, 0.5},
+		{"StdDev", got.StdDev, 1 / math.Sqrt(12)},
+		{"P25", got.P25, 0.25},
+		{"P50", got.P50, 0.5},
+		{"P75", got.P75, 0.75},
+		{"P90", got.P90, 0.9},
+		{"P95", got.P95, 0.95},
+		{"P99", got.P99, 0.99},
+	}
+	for _, tc := range tcs {
+		if math.Abs(tc.got-tc.want) > 0.01 {
+			t.Errorf("got %s = %v, want %v +/- 0.01", tc.name, tc.got, tc.want)
+		}
+	}
+
+	// The quantiles are exactly those of Quantile.
+	if want := mustFloat(digest.Quantile(0.99)); got.P99 != want {
+		t.Errorf("got P99 = %v, want Quantile(0.99) = %v", got.P99, want)
+	}
+
+	if got := tdigest.New().Summarize(); got != (tdigest.SummaryStats{}) {
+		t.Errorf("got %+v for empty digest, want zero stats", got)
+	}
+}
+
+func TestSummaryStats_String(t *testing.T) {
+	stats := tdigest.SummaryStats{
+		Count: 5000, Min: 0.1, Max: 7.4, Mean: 1.3, StdDev: 0.9,
+		P25: 0.8, P50: 1.2, P75: 2, P90: 3, P95: 3.5, P99: 4.5,
+	}
+
+	want := `count   5000
+min     0.1
+max     7.4
+mean    1.3
+stddev  0.9
+p25     0.8
+p50     1.2
+p75     2
+p90     3
+p95     3.5
+p99     4.5
+`
+	if got := stats.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	b, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `{"count":5000,"min":0.1,"max":7.4,"mean":1.3,"stddev":0.9,"p25":0.8,"p50":1.2,"p75":2,"p90":3,"p95":3.5,"p99":4.5}`
+	if got := string(b); got != wantJSON {
+		t.Errorf("got %s, want %s", got, wantJSON)
+	}
+}
@@ -0,0 +1,133 @@
+package tdigest
+
+import "sync"
+
+// SyncTDigest is a TDigest which is safe for use by multiple goroutines.
+// Methods which add observations take a write lock, and queries take a read
+// lock so they may run concurrently.
+type SyncTDigest struct {
+	mu     sync.RWMutex
+	digest *TDigest
+}
+
+// NewSync returns an empty SyncTDigest with the given compression.
+func NewSync(compression float64) *SyncTDigest {
+	return &SyncTDigest{digest: New(WithCompression(compression))}
+}
+
+// Snapshot returns a copy of the current TDigest, which the caller may use and
+// modify without locking.
+func (s *SyncTDigest) Snapshot() *TDigest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.digest.Clone()
+}
+
+// Add adds val, as TDigest.Add does.
+func (s *SyncTDigest) Add(val float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.digest.Add(val)
+}
+
+// AddAll adds each of vals, as TDigest.AddAll does.
+func (s *SyncTDigest) AddAll(vals []float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.digest.AddAll(vals)
+}
+
+// AddWeighted adds count observations of val, as TDigest.AddWeighted does.
+func (s *SyncTDigest) AddWeighted(val, count float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.digest.AddWeighted(val, count)
+}
+
+// Merge merges other into the SyncTDigest. other must not be modified
+// concurrently.
+func (s *SyncTDigest) Merge(other *TDigest) {
+	s.mu.Lock()
+	s.digest.Merge(other)
+	s.mu.Unlock()
+}
+
+// Reset removes every observation, as TDigest.Reset does.
+func (s *SyncTDigest) Reset() {
+	s.mu.Lock()
+	s.digest.Reset()
+	s.mu.Unlock()
+}
+
+// Quantile returns the estimated value of quantile q, as TDigest.Quantile does.
+func (s *SyncTDigest) Quantile(q float64) (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.digest.Quantile(q)
+}
+
+// Quantiles returns the estimated value of each of qs, as TDigest.Quantiles
+// does.
+func (s *SyncTDigest) Quantiles(qs []float64) ([]float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.digest.Quantiles(qs)
+}
+
+// CDF returns the estimated fraction of observations less than or equal to x,
+// as TDigest.CDF does.
+func (s *SyncTDigest) CDF(x float64) (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.digest.CDF(x)
+}
+
+// QuantileRank returns the estimated quantile of x, as TDigest.QuantileRank
+// does.
+func (s *SyncTDigest) QuantileRank(x float64) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.digest.QuantileRank(x)
+}
+
+// Count returns the number of observations added.
+func (s *SyncTDigest) Count() float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.digest.Count()
+}
+
+// Min returns the smallest observation, as TDigest.Min does.
+func (s *SyncTDigest) Min() (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.digest.Min()
+}
+
+// Max returns the largest observation, as TDigest.Max does.
+func (s *SyncTDigest) Max() (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.digest.Max()
+}
+
+// Mean returns the mean of the observations, as TDigest.Mean does.
+func (s *SyncTDigest) Mean() (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.digest.Mean()
+}
+
+// Variance returns the estimated variance, as TDigest.Variance does.
+func (s *SyncTDigest) Variance() (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.digest.Variance()
+}
+
+// StdDev returns the estimated standard deviation, as TDigest.StdDev does.
+func (s *SyncTDigest) StdDev() (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.digest.StdDev()
+}
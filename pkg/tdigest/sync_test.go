@@ -0,0 +1,67 @@
+package tdigest_test
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+// TestSyncTDigest exercises concurrent writes and reads. Run with -race to
+// check for data races.
+func TestSyncTDigest(t *testing.T) {
+	const goroutines = 8
+	const perGoroutine = 10000
+
+	s := tdigest.NewSync(20)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for j := 0; j < perGoroutine; j++ {
+				s.Add(r.Float64())
+				if j%100 == 0 {
+					_ = mustFloat(s.Quantile(0.5))
+					_ = mustFloat(s.CDF(0.5))
+					_ = mustFloat(s.Mean())
+				}
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+
+	if got := s.Count(); got != goroutines*perGoroutine {
+		t.Errorf("got Count() = %v, want %v", got, goroutines*perGoroutine)
+	}
+	if got := mustFloat(s.Quantile(0.5)); got < 0.45 || got > 0.55 {
+		t.Errorf("got Quantile(0.5) = %v, want approximately 0.5", got)
+	}
+}
+
+func TestSyncTDigest_Snapshot(t *testing.T) {
+	s := tdigest.NewSync(20)
+	s.AddAll([]float64{1, 2, 3})
+
+	snapshot := s.Snapshot()
+	s.Add(4)
+	snapshot.Add(5)
+
+	if got := s.Count(); got != 4 {
+		t.Errorf("got Count() = %v, want 4", got)
+	}
+	if got := mustFloat(snapshot.Max()); got != 5 {
+		t.Errorf("got snapshot Max() = %v, want 5", got)
+	}
+	if got := mustFloat(s.Max()); got != 4 {
+		t.Errorf("got Max() = %v, want 4", got)
+	}
+
+	s.Reset()
+	if got := s.Count(); got != 0 {
+		t.Errorf("got Count() = %v after Reset, want 0", got)
+	}
+}
@@ -1,9 +1,10 @@
 package tdigest
 
 import (
+	"errors"
 	"fmt"
 	"math"
-	"strings"
+	"unsafe"
 )
 
 // binarySearchThreshold is when iterating sequentially through a list of
@@ -13,6 +14,19 @@ import (
 // architectures/setups.
 const binarySearchThreshold = 32
 
+// ErrInvalidValue is returned when adding a NaN or infinite value, which
+// have no meaningful position among the centroids.
+var ErrInvalidValue = errors.New("tdigest: value is NaN or infinite")
+
+// ErrEmptyDigest is returned, along with NaN, when querying a TDigest with no
+// observations.
+var ErrEmptyDigest = errors.New("tdigest: digest is empty")
+
+// DefaultCompression is the compression of the zero TDigest, and of those
+// created by New without WithCompression or by constructors which don't take
+// one.
+const DefaultCompression = 100
+
 // centroid represents some set of knowledge about a distribution.
 type centroid struct {
 	mean  float64
@@ -33,14 +47,46 @@ func (c *centroid) String() string {
 func (c *centroid) inc(val float64) {
 	c.count++
 	// special case of averaging weighted means.
-	c.mean += (val - c.mean) / c.count
+	delta := val - c.mean
+	if math.IsInf(delta, 0) {
+		c.mean = shiftMean(c.mean, val, 1/c.count)
+		return
+	}
+	c.mean += delta / c.count
+}
+
+// incN increments the centroid with count observations of val and updates the
+// mean.
+func (c *centroid) incN(val, count float64) {
+	c.count += count
+	delta := (val - c.mean) * count
+	if math.IsInf(delta, 0) {
+		c.mean = shiftMean(c.mean, val, count/c.count)
+		return
+	}
+	c.mean += delta / c.count
+}
+
+// shiftMean returns mean moved a fraction w of the way to val, for when the
+// difference between them overflows, as for values near opposite extremes of
+// float64.
+func shiftMean(mean, val, w float64) float64 {
+	return mean - mean*w + val*w
 }
 
 type TDigest struct {
-	centroids   []*centroid
+	// centroids are stored by value, so scanning them reads contiguous
+	// memory.
+	centroids   []centroid
 	compression float64
 	count       float64
 
+	// min and max are the exact smallest and largest observations added. If
+	// the centroids were set directly, they are the means of the outermost
+	// centroids.
+	min float64
+	max float64
+
 	nCentroids int
 
 	// The cached estimates of the centroids containing the 5% and 95%
@@ -51,22 +97,125 @@ type TDigest struct {
 	// appendLower is whether to append to the lower of the two closest
 	// centroids.
 	appendLower bool
+
+	// rng is the xorshift state used instead of appendLower to choose between
+	// the two closest centroids. Zero if the TDigest is unseeded.
+	rng uint64
+
+	// eagerAdd is whether to skip checking the farther of the two closest
+	// centroids when the closer one has plenty of room.
+	eagerAdd bool
+
+	// losslessFraction is the fraction of observations at each extreme which
+	// are kept as exact single-observation centroids.
+	losslessFraction float64
+
+	// scaleFunc limits the size of centroids. If nil, ScaleFuncK2 is used.
+	scaleFunc ScaleFunc
+
+	// maxCentroids is the most centroids a TDigest created by NewFixed may
+	// have, or 0 if unbounded.
+	maxCentroids int
+
+	// prefixCounts is a Fenwick tree of the centroid counts, used to find the
+	// quantile of a centroid. It is only up to date if prefixValid is true.
+	prefixCounts []float64
+	prefixValid  bool
+
+	// sum is the sum of the observations. If the centroids were set directly
+	// without a sum, it is the sum of the centroids.
+	sum float64
 }
 
 func (d *TDigest) String() string {
-	sb := strings.Builder{}
-	for _, c := range d.centroids {
-		sb.WriteString(fmt.Sprintln(c.String()))
+	return d.StringRepr(4)
+}
+
+// New returns an empty TDigest configured by opts. It panics if opts
+// conflict, such as two different compressions.
+//
+// The zero TDigest is also ready to use, with DefaultCompression.
+func New(opts ...Option) *TDigest {
+	o := options{compression: DefaultCompression}
+	for _, opt := range opts {
+		opt(&o)
 	}
-	return sb.String()
+
+	d := &TDigest{
+		compression:      o.compression,
+		scaleFunc:        o.scaleFunc,
+		losslessFraction: o.losslessFraction,
+	}
+	if o.capacity > 0 {
+		d.centroids = make([]centroid, 0, o.capacity)
+	}
+	return d
 }
 
-func New(compression float64) *TDigest {
+// NewSeeded returns a TDigest which uses seed to choose between the two
+// closest centroids when both have room, rather than alternating between them.
+// TDigests with the same seed built from the same values are identical.
+func NewSeeded(compression float64, seed int64) *TDigest {
+	// Scramble the seed with splitmix64 so nearby seeds give unrelated
+	// sequences, and so the xorshift state is never zero.
+	z := uint64(seed) + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	if z == 0 {
+		z = 0x9e3779b97f4a7c15
+	}
+
 	return &TDigest{
 		compression: compression,
+		rng:         z,
 	}
 }
 
+// SetEagerAdd sets whether the TDigest adds values eagerly.
+//
+// In eager mode, if the closer of the two centroids nearest a value is less
+// than half full, the value is added to it without checking whether the other
+// centroid has room. This trades a small amount of accuracy for speed at high
+// compression.
+func (d *TDigest) SetEagerAdd(eager bool) {
+	d.eagerAdd = eager
+}
+
+// Reset removes every observation from the TDigest, keeping its compression
+// and settings such as SetEagerAdd. Centroids are stored by value and the
+// centroid slice keeps its capacity, so refilling the TDigest to its previous
+// size doesn't allocate.
+func (d *TDigest) Reset() {
+	eagerAdd, losslessFraction, rng, scaleFunc := d.eagerAdd, d.losslessFraction, d.rng, d.scaleFunc
+	maxCentroids := d.maxCentroids
+	d.reset()
+	d.eagerAdd, d.losslessFraction, d.rng, d.scaleFunc = eagerAdd, losslessFraction, rng, scaleFunc
+	d.maxCentroids = maxCentroids
+}
+
+// reset clears the TDigest, keeping its compression and the capacity of its
+// centroid slice.
+func (d *TDigest) reset() {
+	*d = TDigest{
+		compression:  d.compression,
+		centroids:    d.centroids[:0],
+		prefixCounts: d.prefixCounts[:0],
+	}
+}
+
+// Clone returns a deep copy of the TDigest. Later changes to either copy don't
+// affect the other.
+func (d *TDigest) Clone() *TDigest {
+	result := *d
+	// Keep the capacity, so a fixed TDigest's clone doesn't allocate either.
+	result.centroids = make([]centroid, len(d.centroids), cap(d.centroids))
+	copy(result.centroids, d.centroids)
+	result.prefixCounts = make([]float64, len(d.prefixCounts), cap(d.prefixCounts))
+	copy(result.prefixCounts, d.prefixCounts)
+	return &result
+}
+
 // nearest returns the index such that the returned index and its immediate
 // successor are indices of the two closest centroids.
 //
@@ -85,7 +234,8 @@ func (d *TDigest) nearest(val float64) int {
 			right = d.p95Centroid + 1
 		}
 	} else {
-		right = d.p5Centroid
+		// val may equal the mean of the p5 centroid, so it must stay in range.
+		right = d.p5Centroid + 1
 	}
 
 	diff := right - left
@@ -114,9 +264,9 @@ func (d *TDigest) nearest(val float64) int {
 	}
 
 	// Fall back to linear search since it's faster for <=32 elements.
-	for i, c := range d.centroids[left+1:] {
-		if val < c.mean {
-			return left + i
+	for i := left + 1; i < right; i++ {
+		if val < d.centroids[i].mean {
+			return i - 1
 		}
 	}
 	return right - 1
@@ -140,39 +290,67 @@ func (d *TDigest) hasRoom(idx int, c *centroid) bool {
 	// We're at the cached value and the number of centroids has increased,
 	// so actually check if the new weight limit has increased.
 	// While calculating weightLimit is expensive, it's so rare we don't care.
-	ptile := d.quantileOf(idx)
-	c.maxCount = 4 * d.compression * ptile * (1 - ptile) * float64(d.nCentroids)
+	c.maxCount = d.limit(d.quantileOf(idx), d.nCentroids)
 	c.nCentroids = d.nCentroids
 	return c.count < c.maxCount
 }
 
-// quantileOf returns the approximate quantile of centroid idx.
-func (d *TDigest) quantileOf(idx int) float64 {
-	if idx > (d.nCentroids / 2) {
-		// Since we're near the top, compute the quantile by beginning at the
-		// top of the distribution, instead of the bottom. This keeps us from
-		// having to iterate unnecessarily for large percentiles.
-		var total float64
-		for _, c := range d.centroids[idx+1:] {
-			total += c.count
-		}
-		return 1.0 - (d.centroids[idx].count/2+total)/d.count
+// limit returns the most observations a centroid at quantile q may hold when
+// the TDigest has nCentroids centroids.
+func (d *TDigest) limit(q float64, nCentroids int) float64 {
+	if q < d.losslessFraction || q > 1-d.losslessFraction {
+		// Centroids in the lossless tails hold exactly one observation.
+		return 1
 	}
+	return d.scale()(q, d.Compression(), nCentroids)
+}
 
-	var total float64
-	for _, c := range d.centroids[:idx] {
-		total += c.count
+// scale returns the TDigest's ScaleFunc, which is ScaleFuncK2 unless set by
+// WithScaleFunc.
+func (d *TDigest) scale() ScaleFunc {
+	if d.scaleFunc == nil {
+		return scaleK2
+	}
+	return d.scaleFunc
+}
+
+// hasRoomFor returns true if the centroid at idx has room for count more
+// elements.
+func (d *TDigest) hasRoomFor(idx int, c *centroid, count float64) bool {
+	if c.count+count <= c.maxCount {
+		return true
+	}
+	if c.nCentroids == d.nCentroids {
+		// The cached limit is still valid.
+		return false
 	}
-	return (d.centroids[idx].count/2 + total) / d.count
+	d.hasRoom(idx, c)
+	return c.count+count <= c.maxCount
 }
 
-// addCentroid adds a new centroid at index idx with mean mean.
-func (d *TDigest) addCentroid(idx int, mean float64) {
+// quantileOf returns the approximate quantile of centroid idx.
+func (d *TDigest) quantileOf(idx int) float64 {
+	return (d.prefixCount(idx) + d.centroids[idx].count/2) / d.count
+}
+
+// addCentroid adds a new centroid at index idx with mean mean and count count.
+func (d *TDigest) addCentroid(idx int, mean, count float64) {
+	if d.maxCentroids > 0 && d.nCentroids == d.maxCentroids {
+		idx = d.makeRoom(idx, mean)
+	}
+
 	d.nCentroids++
-	d.centroids = append(d.centroids, nil)
+	d.centroids = append(d.centroids, centroid{})
 	copy(d.centroids[idx+1:], d.centroids[idx:])
-	d.centroids[idx] = &centroid{mean: mean, count: 1}
+	d.centroids[idx] = centroid{mean: mean, count: count}
+	d.prefixValid = false
+
+	d.cachePercentileCentroids()
+}
 
+// cachePercentileCentroids updates the cached estimates of the centroids
+// containing the 5% and 95% percentiles.
+func (d *TDigest) cachePercentileCentroids() {
 	if d.nCentroids >= 3 {
 		// Cache the centroids that cover approximately the 5% to 95% case,
 		// since most centroids are small edge cases near the boundary. This way
@@ -190,63 +368,209 @@ func (d *TDigest) addCentroid(idx int, mean float64) {
 	}
 }
 
-// Add adds val to the TDigest.
-func (d *TDigest) Add(val float64) {
+// setCentroids replaces the centroids of the TDigest and recomputes the total
+// count, sum, and cached values. centroids must be sorted by increasing mean.
+func (d *TDigest) setCentroids(centroids []centroid) {
+	d.centroids = centroids
+	d.nCentroids = len(centroids)
+	d.prefixValid = false
+	d.count = 0
+	d.sum = 0
+	for _, c := range centroids {
+		d.count += c.count
+		d.sum += c.mean * c.count
+	}
+	if d.nCentroids > 0 {
+		d.min = centroids[0].mean
+		d.max = centroids[d.nCentroids-1].mean
+	}
+
+	d.p5Centroid = 0
+	d.p95Centroid = 0
+	d.cachePercentileCentroids()
+}
+
+// isValid returns whether val is neither NaN nor infinite.
+func isValid(val float64) bool {
+	// NaN and infinities are exactly the values for which val-val is NaN.
+	return val-val == 0
+}
+
+// Add adds val to the TDigest. If val is NaN or infinite, it returns
+// ErrInvalidValue and leaves the TDigest unchanged.
+func (d *TDigest) Add(val float64) error {
+	if !isValid(val) {
+		return ErrInvalidValue
+	}
 	d.add(val)
 	d.count++
+	d.sum += val
+	return nil
+}
+
+// MustAdd is like Add, but panics if val is NaN or infinite.
+func (d *TDigest) MustAdd(val float64) {
+	if err := d.Add(val); err != nil {
+		panic(fmt.Sprintf("%v: %v", err, val))
+	}
+}
+
+// AddAll adds each of vals to the TDigest, in order. It is equivalent to
+// calling Add for each value, except that if any of vals is NaN or infinite,
+// it returns ErrInvalidValue without adding any of them.
+//
+// vals are deliberately not sorted first. Sorted input fills centroids from
+// one end, so nearly every value finds its neighbours full and the TDigest
+// ends up with orders of magnitude more centroids and slower adds.
+func (d *TDigest) AddAll(vals []float64) error {
+	for _, val := range vals {
+		if !isValid(val) {
+			return ErrInvalidValue
+		}
+	}
+	for _, val := range vals {
+		d.add(val)
+		d.count++
+		d.sum += val
+	}
+	return nil
+}
+
+// AddSorted adds each of vals, which must be sorted in increasing order, to
+// the TDigest. Rather than finding the nearest centroid for each value, it
+// merges vals with the existing centroids in one pass and compresses the
+// result as Recompress does, so the TDigest ends up with a LoadFactor of at
+// most 1.
+//
+// If vals are not sorted, it returns an error, and if any of vals is NaN or
+// infinite, it returns ErrInvalidValue. In either case none of them are added.
+func (d *TDigest) AddSorted(vals []float64) error {
+	for i, val := range vals {
+		if !isValid(val) {
+			return ErrInvalidValue
+		}
+		if i > 0 && val < vals[i-1] {
+			return fmt.Errorf("tdigest: AddSorted values are not sorted at index %d", i)
+		}
+	}
+	if len(vals) == 0 {
+		return nil
+	}
+	d.init()
+	// observe would set both to the last value on an empty TDigest.
+	min, max := vals[0], vals[len(vals)-1]
+	if d.nCentroids > 0 {
+		min, max = math.Min(min, d.min), math.Max(max, d.max)
+	}
+
+	centroids := make([]centroid, 0, d.nCentroids+len(vals))
+	i := 0
+	for _, c := range d.centroids {
+		for ; i < len(vals) && vals[i] < c.mean; i++ {
+			centroids = append(centroids, centroid{mean: vals[i], count: 1})
+		}
+		centroids = append(centroids, centroid{mean: c.mean, count: c.count})
+	}
+	for ; i < len(vals); i++ {
+		centroids = append(centroids, centroid{mean: vals[i], count: 1})
+	}
+
+	count := d.count + float64(len(vals))
+	centroids = d.compressCentroids(centroids, count)
+	sum := d.sum
+	for _, val := range vals {
+		sum += val
+	}
+	d.setCentroids(centroids)
+	d.min, d.max = min, max
+	d.sum = sum
+	return nil
+}
+
+// init gives a zero TDigest DefaultCompression.
+func (d *TDigest) init() {
+	if d.compression == 0 {
+		d.compression = DefaultCompression
+	}
+}
+
+// observe updates the minimum and maximum with val. It must be called before
+// val is added to the centroids.
+func (d *TDigest) observe(val float64) {
+	if d.nCentroids == 0 || val < d.min {
+		d.min = val
+	}
+	if d.nCentroids == 0 || val > d.max {
+		d.max = val
+	}
 }
 
 // add adds a new value, val to the TDigest but does not increment the total
 // count.
 func (d *TDigest) add(val float64) {
+	d.init()
+	d.observe(val)
+
 	// Cover the trivial cases.
 	switch d.nCentroids {
 	case 0:
 		// We haven't added any centroids.
-		d.addCentroid(0, val)
+		d.addCentroid(0, val, 1)
 		return
 	case 1:
 		// There is exactly one centroid.
-		centroid := d.centroids[0]
+		centroid := &d.centroids[0]
 		if centroid.count < d.compression {
 			// It isn't full yet. The first centroid always ends up with
 			// d.compression elements before we create a second centroid.
-			centroid.inc(val)
+			d.inc(0, val)
 			return
 		}
 		// We've got to add the second centroid.
 		if val < centroid.mean {
 			// val is less than the centroid, so it is now the lowest.
-			d.addCentroid(0, val)
+			d.addCentroid(0, val, 1)
 		} else {
 			// val is greater than the centroid, so it is now the highest.
-			d.addCentroid(1, val)
+			d.addCentroid(1, val, 1)
 		}
 		return
 	}
 
 	leftIdx := d.nearest(val)
-	left := d.centroids[leftIdx]
+	left := &d.centroids[leftIdx]
+	if d.eagerAdd && leftIdx < d.nCentroids-1 && left.mean <= val {
+		// Fast path: val is between two centroids and the closer one has lots
+		// of room, so skip checking whether either actually has room.
+		closerIdx := leftIdx + 1
+		if val-left.mean < d.centroids[closerIdx].mean-val {
+			closerIdx = leftIdx
+		}
+		if closer := &d.centroids[closerIdx]; closer.count < 0.5*closer.maxCount {
+			d.inc(closerIdx, val)
+			return
+		}
+	}
 	leftHasRoom := (left.count < left.maxCount) || (left.nCentroids != d.nCentroids && d.hasRoom(leftIdx, left))
 	switch {
 	case val < left.mean:
-		// val is a new minimum.
-		if leftHasRoom {
-			left.inc(val)
+		// val is a new minimum. In lossless mode, new extremes are always in
+		// the tails.
+		if leftHasRoom && d.losslessFraction == 0 {
+			d.inc(leftIdx, val)
 			return
 		}
 		// left has no room, so add a new centroid at index 0.
-		d.addCentroid(0, val)
+		d.addCentroid(0, val, 1)
 		return
 	case leftIdx == len(d.centroids)-1:
 		// val is a new maximum.
-		if leftHasRoom {
+		if leftHasRoom && d.losslessFraction == 0 {
 			// Add val to the leftmost centroid.
-			left := d.centroids[leftIdx]
-			left.inc(val)
+			d.inc(leftIdx, val)
 		} else {
 			// Create a new centroid for the new maximum.
-			d.addCentroid(len(d.centroids), val)
+			d.addCentroid(len(d.centroids), val, 1)
 		}
 		return
 	}
@@ -255,75 +579,500 @@ func (d *TDigest) add(val float64) {
 	// This is the most common case.
 	// Whichever centroid we add val to, it is guaranteed to not change the
 	// ordering of left and right.
-	right := d.centroids[leftIdx+1]
-	rightHasRoom := (right.count < right.maxCount) || (right.nCentroids != d.nCentroids && d.hasRoom(leftIdx + 1, right))
+	right := &d.centroids[leftIdx+1]
+	rightHasRoom := (right.count < right.maxCount) || (right.nCentroids != d.nCentroids && d.hasRoom(leftIdx+1, right))
 	switch {
 	case leftHasRoom && rightHasRoom:
 		// It's most common for both to have room, so check this first.
-		// Flip between the two.
+		// Flip between the two, or pick one at random if seeded.
+		if d.rng != 0 {
+			d.rng ^= d.rng << 13
+			d.rng ^= d.rng >> 7
+			d.rng ^= d.rng << 17
+			d.appendLower = d.rng&1 == 1
+		}
 		if d.appendLower {
-			left.inc(val)
+			d.inc(leftIdx, val)
 		} else {
-			right.inc(val)
+			d.inc(leftIdx+1, val)
 		}
 		d.appendLower = !d.appendLower
 	case leftHasRoom && !rightHasRoom:
-		left.inc(val)
+		d.inc(leftIdx, val)
 	case !leftHasRoom && rightHasRoom:
-		right.inc(val)
+		d.inc(leftIdx+1, val)
 	default:
 		// Neither centroid has room, so create a new one between the two.
-		d.addCentroid(leftIdx+1, val)
+		d.addCentroid(leftIdx+1, val, 1)
 	}
 }
 
-func (d *TDigest) Quantile(q float64) float64 {
-	n := len(d.centroids)
-	switch n {
+// Accumulate adds every observation summarized by other to the TDigest.
+//
+// Centroids of other are inserted directly in order of increasing mean, so no
+// intermediate copy of other is made. Accumulate is a no-op if either d or
+// other is nil.
+func (d *TDigest) Accumulate(other *TDigest) {
+	if d == nil || other == nil {
+		return
+	}
+
+	if d == other {
+		// Accumulating into ourselves would modify the centroids we're
+		// iterating over. Every observation is simply counted twice.
+		for i := range d.centroids {
+			d.centroids[i].count *= 2
+		}
+		d.prefixValid = false
+		d.count *= 2
+		d.sum *= 2
+		return
+	}
+
+	if other.nCentroids == 0 {
+		return
+	}
+	for _, c := range other.centroids {
+		d.addWeighted(c.mean, c.count, false)
+		d.count += c.count
+	}
+	d.sum += other.sum
+	// Centroid means lie between other's extremes, so other's exact extremes
+	// are never worse.
+	d.observe(other.min)
+	d.observe(other.max)
+}
+
+// Merge combines the observations summarized by other into the TDigest, for
+// example to reduce digests built on separate machines. The TDigest keeps its
+// own compression, even if other's differs. other is not modified.
+//
+// Merge is equivalent to Accumulate.
+func (d *TDigest) Merge(other *TDigest) {
+	d.Accumulate(other)
+}
+
+// AddWeighted adds count observations of val to the TDigest, for example to
+// load a histogram bucket. If val is NaN or infinite, it returns
+// ErrInvalidValue and leaves the TDigest unchanged. Panics if count is not
+// positive and finite.
+//
+// If the centroids nearest val don't have room for all count observations,
+// they are filled and the remainder becomes a new centroid at val.
+func (d *TDigest) AddWeighted(val, count float64) error {
+	if !(count > 0) || math.IsInf(count, 1) {
+		panic(fmt.Sprintf("tdigest: AddWeighted count must be positive and finite, got %v", count))
+	}
+	if !isValid(val) {
+		return ErrInvalidValue
+	}
+	d.addWeighted(val, count, true)
+	d.count += count
+	d.sum += val * count
+	return nil
+}
+
+// addWeighted adds count observations of val to the TDigest but does not
+// increment the total count.
+//
+// If split is false, the weight is never split between centroids: if the
+// nearest centroids don't have room for all count observations, a new centroid
+// is created. If split is true, the nearest centroid is filled first.
+func (d *TDigest) addWeighted(val, count float64, split bool) {
+	d.init()
+	d.observe(val)
+
+	switch d.nCentroids {
 	case 0:
-		return math.NaN()
+		d.addCentroid(0, val, count)
+		return
 	case 1:
+		centroid := &d.centroids[0]
+		if centroid.count+count <= d.compression {
+			d.incN(0, val, count)
+			return
+		}
+		if split && centroid.count < d.compression {
+			room := d.compression - centroid.count
+			d.incN(0, val, room)
+			count -= room
+		}
+		if val < centroid.mean {
+			d.addCentroid(0, val, count)
+		} else {
+			d.addCentroid(1, val, count)
+		}
+		return
+	}
+
+	leftIdx := d.nearest(val)
+	left := &d.centroids[leftIdx]
+	leftHasRoom := d.hasRoomFor(leftIdx, left, count)
+	switch {
+	case val < left.mean:
+		// val is a new minimum.
+		if d.losslessFraction == 0 {
+			if leftHasRoom {
+				d.incN(leftIdx, val, count)
+				return
+			}
+			if split {
+				count = d.fill(leftIdx, val, count)
+			}
+		}
+		d.addCentroid(0, val, count)
+		return
+	case leftIdx == len(d.centroids)-1:
+		// val is a new maximum.
+		if d.losslessFraction == 0 {
+			if leftHasRoom {
+				d.incN(leftIdx, val, count)
+				return
+			}
+			if split {
+				count = d.fill(leftIdx, val, count)
+			}
+		}
+		d.addCentroid(len(d.centroids), val, count)
+		return
+	}
+
+	right := &d.centroids[leftIdx+1]
+	rightHasRoom := d.hasRoomFor(leftIdx+1, right, count)
+	closerIsLeft := val-left.mean < right.mean-val
+	switch {
+	case leftHasRoom && rightHasRoom:
+		// Weighted values move centroid means much more than single
+		// observations, so prefer the closer centroid rather than alternating.
+		if closerIsLeft {
+			d.incN(leftIdx, val, count)
+		} else {
+			d.incN(leftIdx+1, val, count)
+		}
+	case leftHasRoom:
+		d.incN(leftIdx, val, count)
+	case rightHasRoom:
+		d.incN(leftIdx+1, val, count)
+	default:
+		if split {
+			if closerIsLeft {
+				count = d.fill(leftIdx, val, count)
+			} else {
+				count = d.fill(leftIdx+1, val, count)
+			}
+		}
+		d.addCentroid(leftIdx+1, val, count)
+	}
+}
+
+// Count returns the number of observations in the TDigest.
+func (d *TDigest) Count() float64 {
+	return d.count
+}
+
+// Min returns the smallest observation added to the TDigest, or
+// ErrEmptyDigest if it is empty.
+//
+// For a TDigest which was unmarshalled or derived from another, such as by
+// Invert, this is the mean of the lowest centroid.
+func (d *TDigest) Min() (float64, error) {
+	if d.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+	return d.min, nil
+}
+
+// Max returns the largest observation added to the TDigest, or ErrEmptyDigest
+// if it is empty.
+//
+// For a TDigest which was unmarshalled or derived from another, such as by
+// Invert, this is the mean of the highest centroid.
+func (d *TDigest) Max() (float64, error) {
+	if d.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+	return d.max, nil
+}
+
+// LoadFactor returns how full the TDigest's centroids are: the count divided by
+// the total of each centroid's limit, as Add computes them. Limits grow with
+// the number of centroids, so a TDigest has no maximum number of centroids.
+//
+// Near 0, centroids have plenty of room and new values mostly join them. Near
+// 1, most centroids are full, as after Recompress, so new values mostly create
+// new centroids. A TDigest built with Add stays between the two.
+func (d *TDigest) LoadFactor() float64 {
+	if d.nCentroids == 0 {
+		return 0
+	}
+	var capacity, total float64
+	for _, c := range d.centroids {
+		capacity += d.limit((total+c.count/2)/d.count, d.nCentroids)
+		total += c.count
+	}
+	return d.count / capacity
+}
+
+// IsFullyCompressed returns true if the TDigest's centroids are nearly all
+// full, so Recompress would merge few of them.
+func (d *TDigest) IsFullyCompressed() bool {
+	return d.LoadFactor() > 0.95
+}
+
+// Compression returns the TDigest's compression. For the zero TDigest this is
+// DefaultCompression.
+func (d *TDigest) Compression() float64 {
+	if d.compression == 0 {
+		return DefaultCompression
+	}
+	return d.compression
+}
+
+// CentroidCount returns the number of centroids in the TDigest.
+func (d *TDigest) CentroidCount() int {
+	return d.nCentroids
+}
+
+// MemoryEstimate returns a rough estimate of the bytes held by the TDigest:
+// the TDigest itself and the capacity of its slices of centroids and prefix
+// counts. It ignores options such as WithScaleFunc.
+func (d *TDigest) MemoryEstimate() int {
+	return int(unsafe.Sizeof(*d)) +
+		cap(d.centroids)*int(unsafe.Sizeof(centroid{})) +
+		cap(d.prefixCounts)*int(unsafe.Sizeof(float64(0)))
+}
+
+// Mean returns the mean of the observations, or ErrEmptyDigest if the TDigest
+// is empty.
+//
+// The sum of the observations is kept as they are added, so this is exact up
+// to floating point error and doesn't scan the centroids.
+func (d *TDigest) Mean() (float64, error) {
+	if d.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+	return d.mean(), nil
+}
+
+// mean returns the mean of the observations, or NaN if the TDigest is empty.
+func (d *TDigest) mean() float64 {
+	return d.sum / d.count
+}
+
+// Variance returns the weighted variance of the centroid means, or
+// ErrEmptyDigest if the TDigest is empty.
+//
+// Since the spread of observations within each centroid is lost, this
+// underestimates the variance of the observations, especially while the
+// TDigest has few centroids.
+func (d *TDigest) Variance() (float64, error) {
+	if d.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+	return d.variance(), nil
+}
+
+// variance is Variance, but returns NaN if the TDigest is empty.
+func (d *TDigest) variance() float64 {
+	mean := d.mean()
+	var sum float64
+	for _, c := range d.centroids {
+		diff := c.mean - mean
+		sum += c.count * diff * diff
+	}
+	return sum / d.count
+}
+
+// StdDev returns the square root of Variance, or ErrEmptyDigest if the TDigest
+// is empty.
+func (d *TDigest) StdDev() (float64, error) {
+	if d.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+	return math.Sqrt(d.variance()), nil
+}
+
+// Quantile returns the estimated value of quantile q, or ErrEmptyDigest if the
+// TDigest is empty. q is clamped to [0, 1].
+//
+// Quantile is monotonic: if q1 <= q2, then Quantile(q1) <= Quantile(q2).
+// Quantile(0) and Quantile(1) are exactly Min and Max, and no estimate lies
+// outside them.
+func (d *TDigest) Quantile(q float64) (float64, error) {
+	if d.nCentroids == 0 {
+		return math.NaN(), ErrEmptyDigest
+	}
+	return d.quantile(q), nil
+}
+
+// MustQuantile is like Quantile, but panics if the TDigest is empty. Use it
+// only where the TDigest is known to have observations.
+func (d *TDigest) MustQuantile(q float64) float64 {
+	v, err := d.Quantile(q)
+	if err != nil {
+		panic(err.Error())
+	}
+	return v
+}
+
+// quantile is Quantile, but returns NaN if the TDigest is empty.
+func (d *TDigest) quantile(q float64) float64 {
+	n := len(d.centroids)
+	switch {
+	case n == 0:
+		return math.NaN()
+	case q <= 0:
+		return d.min
+	case q >= 1:
+		return d.max
+	case n == 1:
 		return d.centroids[0].mean
 	}
 
-	if q < 0 {
-		q = 0
-	} else if q > 1 {
-		q = 1
+	if n <= binarySearchThreshold && d.hasWholeCounts() {
+		// With few centroids, interpolating between centroid midpoints has
+		// high relative error, so compute the quantile as if each centroid
+		// were count observations of its mean.
+		return d.clamp(d.exactQuantile(q))
+	}
+	return d.clamp(d.interpolatedQuantile(q))
+}
+
+// clamp limits val to between the smallest and largest observations, since
+// extrapolating beyond the outermost centroids can overshoot them.
+func (d *TDigest) clamp(val float64) float64 {
+	if val < d.min {
+		return d.min
+	} else if val > d.max {
+		return d.max
+	}
+	return val
+}
+
+// hasWholeCounts returns whether the TDigest has at least two observations and
+// every centroid holds a whole number of them, as exactQuantile requires.
+// Weighted values, FromCentroids, and Interpolate can give fractional counts.
+func (d *TDigest) hasWholeCounts() bool {
+	if d.count < 2 {
+		return false
+	}
+	for _, c := range d.centroids {
+		if c.count != math.Trunc(c.count) {
+			return false
+		}
+	}
+	return true
+}
+
+// exactQuantile returns the quantile q, treating the observations of each
+// centroid as ranked consecutively with the centroid's mean at the middle
+// rank. Values between the middle ranks of neighboring centroids are linearly
+// interpolated.
+//
+// If every centroid has a count of 1, this is the exact sample quantile.
+// d.centroids must contain at least 2 elements, hasWholeCounts must be true,
+// and q must be in [0, 1].
+func (d *TDigest) exactQuantile(q float64) float64 {
+	// The rank of the quantile, where observations are ranked from 0.
+	rank := q * (d.count - 1)
+
+	n := len(d.centroids)
+	var total, prevMiddle float64
+	for i, c := range d.centroids {
+		middle := total + (c.count-1)/2
+		if rank <= middle {
+			if i == 0 {
+				// rank is below the first centroid's middle rank, so
+				// extrapolate from the first two centroids.
+				next := &d.centroids[1]
+				nextMiddle := c.count + (next.count-1)/2
+				slope := (next.mean - c.mean) / (nextMiddle - middle)
+				return c.mean + slope*(rank-middle)
+			}
+			prev := &d.centroids[i-1]
+			return lerp(prev.mean, c.mean, (rank-prevMiddle)/(middle-prevMiddle))
+		}
+		total += c.count
+		prevMiddle = middle
 	}
 
+	// rank is above the last centroid's middle rank, so extrapolate from the
+	// last two centroids.
+	last := &d.centroids[n-1]
+	prev := &d.centroids[n-2]
+	prevMiddle = total - last.count - (prev.count+1)/2
+	lastMiddle := total - (last.count+1)/2
+	slope := (last.mean - prev.mean) / (lastMiddle - prevMiddle)
+	return last.mean + slope*(rank-lastMiddle)
+}
+
+// interpolatedQuantile returns the quantile q by interpolating between the
+// midpoints of centroids.
+//
+// d.centroids must contain at least 2 elements and q must be in [0, 1].
+func (d *TDigest) interpolatedQuantile(q float64) float64 {
 	// rescale into count units.
 	q = d.count * q
 
+	// Stop at the last centroid even if q is past its midpoint, so qTotal
+	// is always the count before idx.
+	n := len(d.centroids)
 	var qTotal float64
-	var idx int
-	for i, c := range d.centroids {
+	idx := n - 1
+	for i, c := range d.centroids[:n-1] {
 		if qTotal+c.count/2 >= q {
 			idx = i
 			break
 		}
 		qTotal += c.count
-		idx = i
 	}
 
-	switch idx {
-	case 0:
-		c0 := d.centroids[0]
-		c1 := d.centroids[1]
+	return d.interpolateAt(q, idx, qTotal)
+}
+
+// interpolateAt returns the value at rescaled quantile q, given the index idx
+// of the first centroid whose midpoint is at least q and the total count
+// qTotal of the centroids before it. If no centroid's midpoint is at least q,
+// idx is the last centroid.
+//
+// The result is piecewise linear in q between the midpoints of neighboring
+// centroids, so since centroids are sorted by mean it never decreases as q
+// increases.
+func (d *TDigest) interpolateAt(q float64, idx int, qTotal float64) float64 {
+	if idx == 0 {
+		c0 := &d.centroids[0]
+		c1 := &d.centroids[1]
 		slope := 2 * (c1.mean - c0.mean) / (c1.count + c0.count)
 		deltaQ := q - c0.count/2
 		return c0.mean + slope*deltaQ
-	case n - 1:
-		c0 := d.centroids[n-2]
-		c1 := d.centroids[n-1]
+	}
+
+	c0 := &d.centroids[idx-1]
+	c1 := &d.centroids[idx]
+	middle0 := qTotal - c0.count/2
+	middle1 := qTotal + c1.count/2
+	if q > middle1 {
+		// Only possible for the last centroid, so extrapolate.
 		slope := 2 * (c1.mean - c0.mean) / (c1.count + c0.count)
-		deltaQ := q - (qTotal - c1.count/2)
-		return c1.mean + slope*deltaQ
+		return c1.mean + slope*(q-middle1)
 	}
+	return lerp(c0.mean, c1.mean, (q-middle0)/(middle1-middle0))
+}
 
-	c0 := d.centroids[idx-1]
-	c1 := d.centroids[idx]
-	slope := 2 * (c1.mean - c0.mean) / (c1.count + c0.count)
-	deltaQ := q - (c1.count/2 + qTotal)
-	return c1.mean + slope*deltaQ
+// lerp returns the value a fraction t of the way from a to b. Unlike
+// a + t*(b-a), it is exactly b at t = 1, and it never decreases as t increases
+// if a <= b. Otherwise, when a and b are far apart, rounding can make
+// interpolation between neighboring centroids overlap, so quantiles decrease.
+func lerp(a, b, t float64) float64 {
+	if (a <= 0 && b >= 0) || (a >= 0 && b <= 0) {
+		return t*b + (1-t)*a
+	}
+	if t == 1 {
+		return b
+	}
+	x := a + t*(b-a)
+	if (t > 1) == (b > a) {
+		return math.Max(b, x)
+	}
+	return math.Min(b, x)
 }
@@ -0,0 +1,55 @@
+package tdigest_test
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+// fuzzValues encodes vals as the input of FuzzAdd.
+func fuzzValues(vals ...float64) []byte {
+	b := make([]byte, 0, 8*len(vals))
+	for _, v := range vals {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+	}
+	return b
+}
+
+func FuzzAdd(f *testing.F) {
+	f.Add(fuzzValues())
+	f.Add(fuzzValues(1))
+	f.Add(fuzzValues(5, 5, 5, 5, 5, 5, 5, 5, 5, 5))
+	f.Add(fuzzValues(-math.MaxFloat64, math.MaxFloat64, -math.MaxFloat64, math.MaxFloat64))
+	f.Add(fuzzValues(0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1))
+	f.Add(fuzzValues(math.SmallestNonzeroFloat64, -math.SmallestNonzeroFloat64, 0))
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		// A small compression keeps many centroids, exercising the binary
+		// search in nearest.
+		digest := tdigest.New(tdigest.WithCompression(0.5))
+		for ; len(b) >= 8; b = b[8:] {
+			v := math.Float64frombits(binary.LittleEndian.Uint64(b))
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			digest.Add(v)
+		}
+		if digest.Count() == 0 {
+			return
+		}
+
+		prev := math.Inf(-1)
+		for _, q := range []float64{0.5, 0.9, 0.99} {
+			got := mustFloat(digest.Quantile(q))
+			if math.IsNaN(got) || math.IsInf(got, 0) {
+				t.Fatalf("got Quantile(%v) = %v, want finite", q, got)
+			}
+			if got < prev {
+				t.Fatalf("got Quantile(%v) = %v, below the previous quantile %v", q, got, prev)
+			}
+			prev = got
+		}
+	})
+}
@@ -0,0 +1,415 @@
+package tdigest
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func newUniform(compression float64, n int, seed int64) *TDigest {
+	r := rand.New(rand.NewSource(seed))
+	d := New(WithCompression(compression))
+	for i := 0; i < n; i++ {
+		d.Add(r.Float64())
+	}
+	return d
+}
+
+func TestTDigest_Accumulate(t *testing.T) {
+	a := newUniform(100, 10000, 1)
+	b := newUniform(100, 5000, 2)
+
+	// The workaround Accumulate replaces.
+	want := newUniform(100, 10000, 1)
+	for _, c := range b.centroids {
+		want.addWeighted(c.mean, c.count, false)
+		want.count += c.count
+	}
+
+	a.Accumulate(b)
+
+	if a.count != 15000 {
+		t.Errorf("got count %v, want %v", a.count, 15000)
+	}
+	for i := 0; i <= 10; i++ {
+		q := float64(i) / 10
+		if got, want := a.quantile(q), want.quantile(q); got != want {
+			t.Errorf("Quantile(%v): got %v, want %v", q, got, want)
+		}
+	}
+	if b.count != 5000 {
+		t.Errorf("got count %v for accumulated digest, want %v", b.count, 5000)
+	}
+}
+
+func TestTDigest_Accumulate_Self(t *testing.T) {
+	// With more than binarySearchThreshold centroids, quantiles depend only on
+	// the relative counts of centroids, so doubling every count leaves them
+	// exactly unchanged. Smaller digests rank each observation, so doubling
+	// them moves quantiles as duplicating a sample does.
+	d := newUniform(20, 10000, 1)
+	want := newUniform(20, 10000, 1)
+	if d.nCentroids <= binarySearchThreshold {
+		t.Fatalf("got %d centroids, want more than %d", d.nCentroids, binarySearchThreshold)
+	}
+
+	d.Accumulate(d)
+
+	if d.count != 20000 {
+		t.Errorf("got count %v, want %v", d.count, 20000)
+	}
+	for i := 0; i <= 10; i++ {
+		q := float64(i) / 10
+		if got, want := d.quantile(q), want.quantile(q); got != want {
+			t.Errorf("Quantile(%v): got %v, want %v", q, got, want)
+		}
+	}
+}
+
+func TestTDigest_Accumulate_Nil(t *testing.T) {
+	var nilDigest *TDigest
+	nilDigest.Accumulate(newUniform(100, 10, 1))
+
+	d := newUniform(100, 10, 1)
+	d.Accumulate(nil)
+	if d.count != 10 {
+		t.Errorf("got count %v, want %v", d.count, 10)
+	}
+
+	empty := New(WithCompression(100))
+	empty.Accumulate(New(WithCompression(100)))
+	if empty.count != 0 || empty.nCentroids != 0 {
+		t.Errorf("got count %v with %d centroids, want empty", empty.count, empty.nCentroids)
+	}
+}
+
+// sampleQuantile returns the sample quantile q of sorted, linearly
+// interpolating between adjacent observations.
+func sampleQuantile(sorted []float64, q float64) float64 {
+	rank := q * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	if lo == len(sorted)-1 {
+		return sorted[lo]
+	}
+	return sorted[lo] + (rank-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
+
+func TestTDigest_exactQuantile(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for n := 2; n <= binarySearchThreshold; n++ {
+		vals := make([]float64, n)
+		centroids := make([]centroid, n)
+		for i := range vals {
+			vals[i] = r.NormFloat64()
+		}
+		sort.Float64s(vals)
+		for i, val := range vals {
+			centroids[i] = centroid{mean: val, count: 1}
+		}
+		d := New(WithCompression(100))
+		d.setCentroids(centroids)
+
+		var exactErr, interpolatedErr float64
+		for i := 0; i <= 100; i++ {
+			q := float64(i) / 100
+			want := sampleQuantile(vals, q)
+			exactErr += math.Abs(d.exactQuantile(q) - want)
+			interpolatedErr += math.Abs(d.interpolatedQuantile(q) - want)
+		}
+
+		if exactErr > 1e-9 {
+			t.Errorf("%d centroids: got total error %v for exact path, want 0", n, exactErr)
+		}
+		if exactErr > interpolatedErr {
+			t.Errorf("%d centroids: got total error %v for exact path, greater than %v for interpolated path",
+				n, exactErr, interpolatedErr)
+		}
+	}
+}
+
+func TestTDigest_exactQuantile_Weighted(t *testing.T) {
+	// Two centroids, each holding observations spread evenly around its mean.
+	d := New(WithCompression(100))
+	d.setCentroids([]centroid{{mean: 1, count: 3}, {mean: 4, count: 3}})
+
+	// Ranks 1 and 4 are the middles of the centroids.
+	for _, tc := range []struct {
+		q, want float64
+	}{{0, 0}, {0.2, 1}, {0.5, 2.5}, {0.8, 4}, {1, 5}} {
+		if got := d.exactQuantile(tc.q); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("exactQuantile(%v): got %v, want %v", tc.q, got, tc.want)
+		}
+	}
+}
+
+func newSingletons(n int) *TDigest {
+	centroids := make([]centroid, n)
+	for i := range centroids {
+		centroids[i] = centroid{mean: float64(i), count: 1}
+	}
+	d := New(WithCompression(100))
+	d.setCentroids(centroids)
+	return d
+}
+
+func BenchmarkTDigest_exactQuantile(b *testing.B) {
+	d := newSingletons(binarySearchThreshold)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = d.exactQuantile(float64(i%100) / 100)
+	}
+}
+
+func BenchmarkTDigest_interpolatedQuantile(b *testing.B) {
+	d := newSingletons(binarySearchThreshold)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = d.interpolatedQuantile(float64(i%100) / 100)
+	}
+}
+
+func TestTDigest_AddWeighted(t *testing.T) {
+	d := newUniform(20, 10000, 1)
+
+	d.AddWeighted(0.5, 2.5)
+	d.AddWeighted(-1, 1000)
+	d.AddWeighted(2, 1)
+
+	if d.count != 11003.5 {
+		t.Errorf("got count %v, want %v", d.count, 11003.5)
+	}
+	var total float64
+	for _, c := range d.centroids {
+		total += c.count
+	}
+	if total != d.count {
+		t.Errorf("got centroid counts summing to %v, want %v", total, d.count)
+	}
+	for i := 1; i < d.nCentroids; i++ {
+		if d.centroids[i].mean < d.centroids[i-1].mean {
+			t.Fatalf("got centroid %d with mean %v less than previous %v", i, d.centroids[i].mean, d.centroids[i-1].mean)
+		}
+	}
+	if got := d.centroids[0].mean; got != -1 {
+		t.Errorf("got minimum centroid mean %v, want -1", got)
+	}
+}
+
+func TestTDigest_AddWeighted_Split(t *testing.T) {
+	d := New(WithCompression(10))
+	for i := 0; i < 5; i++ {
+		d.Add(0)
+	}
+
+	// The first centroid only has room for 5 more observations, so the
+	// remaining 15 become a new centroid.
+	d.AddWeighted(1, 20)
+
+	want := []centroid{{mean: 0.5, count: 10}, {mean: 1, count: 15}}
+	if d.nCentroids != len(want) {
+		t.Fatalf("got %d centroids, want %d", d.nCentroids, len(want))
+	}
+	for i, c := range want {
+		if got := d.centroids[i]; got.mean != c.mean || got.count != c.count {
+			t.Errorf("got centroid %d = %v, want %v", i, got, &c)
+		}
+	}
+
+	// Between two full centroids, the closer one is filled before a new
+	// centroid is created.
+	d = newUniform(20, 10000, 1)
+	idx := d.nCentroids / 2
+	left, right := &d.centroids[idx], &d.centroids[idx+1]
+	d.hasRoom(idx, left)
+	d.hasRoom(idx+1, right)
+	room := left.maxCount - left.count
+	val := left.mean + (right.mean-left.mean)/4
+	n := d.nCentroids
+
+	d.AddWeighted(val, room+1000)
+
+	if d.nCentroids != n+1 {
+		t.Errorf("got %d centroids, want %d", d.nCentroids, n+1)
+	}
+	// The new centroid is to the right, so left keeps its index, but adding
+	// it may have moved the centroids.
+	left = &d.centroids[idx]
+	if room > 0 && left.count != left.maxCount {
+		t.Errorf("got left centroid count %v, want it filled to %v", left.count, left.maxCount)
+	}
+}
+
+func TestTDigest_AddWeighted_Histogram(t *testing.T) {
+	// A digest built from histogram buckets should match one built from the
+	// individual observations.
+	weighted, unweighted := New(WithCompression(20)), New(WithCompression(20))
+	for bucket := 0; bucket < 100; bucket++ {
+		val := float64(bucket) / 100
+		count := float64(1 + bucket%7)
+		weighted.AddWeighted(val, count)
+		for i := 0; i < int(count); i++ {
+			unweighted.Add(val)
+		}
+	}
+
+	if weighted.count != unweighted.count {
+		t.Errorf("got count %v, want %v", weighted.count, unweighted.count)
+	}
+	for _, q := range []float64{0.1, 0.5, 0.9} {
+		if got, want := weighted.quantile(q), unweighted.quantile(q); math.Abs(got-want) > 0.05 {
+			t.Errorf("got Quantile(%v) = %v, want %v +/- 0.05", q, got, want)
+		}
+	}
+}
+
+func TestTDigest_AddWeighted_NonPositive(t *testing.T) {
+	for _, count := range []float64{0, -1, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("got no panic for count %v", count)
+				}
+			}()
+			New(WithCompression(20)).AddWeighted(1, count)
+		}()
+	}
+}
+
+func TestTDigest_Reset(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	first := make([]float64, 1000)
+	second := make([]float64, 1000)
+	for i := range first {
+		first[i] = r.NormFloat64()
+		second[i] = 10 + r.Float64()
+	}
+
+	d := New(WithCompression(20))
+	d.SetEagerAdd(true)
+	d.AddAll(first)
+	d.Reset()
+	d.AddAll(second)
+
+	want := New(WithCompression(20))
+	want.SetEagerAdd(true)
+	want.AddAll(second)
+	if got := d.String(); got != want.String() {
+		t.Errorf("got centroids %v after Reset, want %v", got, want)
+	}
+	if d.min != want.min || d.max != want.max || d.Count() != want.Count() {
+		t.Errorf("got count %v in [%v, %v], want %v in [%v, %v]",
+			d.count, d.min, d.max, want.count, want.min, want.max)
+	}
+
+	// Centroids are stored by value, so refilling reuses the slice and
+	// doesn't allocate at all.
+	backing := &d.centroids[:1][0]
+	allocs := testing.AllocsPerRun(10, func() {
+		d.Reset()
+		d.AddAll(second)
+	})
+	if &d.centroids[:1][0] != backing {
+		t.Error("got new centroid slice after Reset")
+	}
+	if allocs != 0 {
+		t.Errorf("got %v allocations refilling after Reset, want 0", allocs)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := newUniform(20, 10000, 1).Validate(); err != nil {
+		t.Fatalf("got error %v for valid digest", err)
+	}
+	if err := New(WithCompression(20)).Validate(); err != nil {
+		t.Fatalf("got error %v for empty digest", err)
+	}
+
+	tcs := []struct {
+		name    string
+		corrupt func(d *TDigest)
+		want    []string
+	}{{
+		name:    "unsorted",
+		corrupt: func(d *TDigest) { d.centroids[3].mean = 2 },
+		want:    []string{"centroid 4 with mean"},
+	}, {
+		name:    "non-positive count",
+		corrupt: func(d *TDigest) { d.centroids[0].count = 0 },
+		want:    []string{"centroid 0 has non-positive count", "centroid counts sum to"},
+	}, {
+		name:    "nCentroids",
+		corrupt: func(d *TDigest) { d.nCentroids++ },
+		want:    []string{"nCentroids is"},
+	}, {
+		name:    "count",
+		corrupt: func(d *TDigest) { d.count++ },
+		want:    []string{"centroid counts sum to"},
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			d := newUniform(20, 10000, 1)
+			tc.corrupt(d)
+
+			err := d.Validate()
+			if err == nil {
+				t.Fatal("got no error")
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("got error %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+// TestProtoFieldNumbers checks that the hand-written Protocol Buffer encoding
+// uses the field numbers in proto/tdigest.proto.
+func TestProtoFieldNumbers(t *testing.T) {
+	b, err := os.ReadFile("../../proto/tdigest.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fields maps "Message.field" to its field number.
+	fields := make(map[string]int)
+	message := ""
+	messageRE := regexp.MustCompile(`^message (\w+) {`)
+	fieldRE := regexp.MustCompile(`^(?:repeated )?\w+ (\w+) = (\d+);`)
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if m := messageRE.FindStringSubmatch(line); m != nil {
+			message = m[1]
+		} else if m := fieldRE.FindStringSubmatch(line); m != nil {
+			fields[message+"."+m[1]], _ = strconv.Atoi(m[2])
+		}
+	}
+
+	tcs := []struct {
+		field string
+		want  int
+	}{
+		{field: "TDigest.compression", want: protoTDigestCompression},
+		{field: "TDigest.count", want: protoTDigestCount},
+		{field: "TDigest.centroids", want: protoTDigestCentroids},
+		{field: "TDigest.sum", want: protoTDigestSum},
+		{field: "Centroid.mean", want: protoCentroidMean},
+		{field: "Centroid.count", want: protoCentroidCount},
+	}
+	for _, tc := range tcs {
+		if got, ok := fields[tc.field]; !ok || got != tc.want {
+			t.Errorf("got %v = %v in proto/tdigest.proto, want %v", tc.field, got, tc.want)
+		}
+	}
+	if len(fields) != len(tcs) {
+		t.Errorf("got %d fields in proto/tdigest.proto, want %d", len(fields), len(tcs))
+	}
+}
@@ -1,7 +1,11 @@
 package tdigest_test
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"math/rand"
+	"sort"
 	"testing"
 	"time"
 
@@ -9,7 +13,7 @@ import (
 )
 
 func BenchmarkTDigest_Add(b *testing.B) {
-	digest := tdigest.New(500)
+	digest := tdigest.New(tdigest.WithCompression(500))
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -26,3 +30,736 @@ func BenchmarkRand(b *testing.B) {
 		_ = r.Float64()
 	}
 }
+
+func BenchmarkTDigest_Add_Compression1000(b *testing.B) {
+	digest := tdigest.New(tdigest.WithCompression(1000))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := rand.Float64()
+		digest.Add(r)
+	}
+}
+
+func BenchmarkTDigest_Add_Compression1000_Eager(b *testing.B) {
+	digest := tdigest.New(tdigest.WithCompression(1000))
+	digest.SetEagerAdd(true)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := rand.Float64()
+		digest.Add(r)
+	}
+}
+
+func TestTDigest_LoadFactor(t *testing.T) {
+	var zero tdigest.TDigest
+	if got := zero.LoadFactor(); got != 0 {
+		t.Errorf("got LoadFactor() = %v for zero TDigest, want 0", got)
+	}
+
+	// The first centroid is full once it holds compression values.
+	digest := tdigest.New(tdigest.WithCompression(1000))
+	for i := 0; i < 1000; i++ {
+		digest.Add(float64(i))
+	}
+	if got := digest.LoadFactor(); got != 1 {
+		t.Errorf("got LoadFactor() = %v for one full centroid, want 1", got)
+	}
+	if !digest.IsFullyCompressed() {
+		t.Error("got IsFullyCompressed() = false for one full centroid")
+	}
+
+	// Limits grow with the number of centroids, so Add keeps the LoadFactor
+	// about the same whatever the compression and count.
+	for _, compression := range []float64{10, 1000} {
+		digest := newUniformRange(compression, 100000, 0, 1, 1)
+		if got := digest.LoadFactor(); got < 0.5 || got > 0.9 {
+			t.Errorf("got LoadFactor() = %v at compression %v, want between 0.5 and 0.9", got, compression)
+		}
+		if digest.IsFullyCompressed() {
+			t.Errorf("got IsFullyCompressed() = true at compression %v", compression)
+		}
+
+		digest.Reset()
+		if got := digest.LoadFactor(); got != 0 {
+			t.Errorf("got LoadFactor() = %v after Reset, want 0", got)
+		}
+	}
+}
+
+func TestTDigest_Getters(t *testing.T) {
+	var zero tdigest.TDigest
+	if got := zero.Compression(); got != tdigest.DefaultCompression {
+		t.Errorf("got Compression() = %v for zero TDigest, want %v", got, tdigest.DefaultCompression)
+	}
+
+	digest := tdigest.New(tdigest.WithCompression(20))
+	if got := digest.Compression(); got != 20 {
+		t.Errorf("got Compression() = %v, want 20", got)
+	}
+	if got := digest.CentroidCount(); got != 0 {
+		t.Errorf("got CentroidCount() = %v for empty digest, want 0", got)
+	}
+	empty := digest.MemoryEstimate()
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		digest.Add(r.Float64())
+	}
+	if got, want := digest.CentroidCount(), len(digest.Centroids()); got != want {
+		t.Errorf("got CentroidCount() = %v, want %v", got, want)
+	}
+	// Each centroid holds at least its mean and count.
+	if got, want := digest.MemoryEstimate(), empty+16*digest.CentroidCount(); got < want {
+		t.Errorf("got MemoryEstimate() = %v, want at least %v", got, want)
+	}
+}
+
+func TestDeterminism(t *testing.T) {
+	tcs := []struct {
+		name string
+		new  func() *tdigest.TDigest
+	}{{
+		name: "unseeded",
+		new:  func() *tdigest.TDigest { return tdigest.New(tdigest.WithCompression(20)) },
+	}, {
+		name: "seeded",
+		new:  func() *tdigest.TDigest { return tdigest.NewSeeded(20, 42) },
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			d1, d2 := tc.new(), tc.new()
+			for i := 0; i < 100000; i++ {
+				val := r.NormFloat64()
+				d1.Add(val)
+				d2.Add(val)
+			}
+
+			for i := 0; i < 100; i++ {
+				q := float64(i) / 99
+				if got, want := mustFloat(d2.Quantile(q)), mustFloat(d1.Quantile(q)); got != want {
+					t.Errorf("got Quantile(%v) = %v, want %v", q, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewSeeded_DifferentSeeds(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	d1, d2 := tdigest.NewSeeded(20, 1), tdigest.NewSeeded(20, 2)
+	for i := 0; i < 10000; i++ {
+		val := r.NormFloat64()
+		d1.Add(val)
+		d2.Add(val)
+	}
+
+	if d1.String() == d2.String() {
+		t.Error("got identical digests for different seeds")
+	}
+}
+
+func TestTDigest_SetEagerAdd(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	vals := make([]float64, 100000)
+	for i := range vals {
+		vals[i] = r.NormFloat64()
+	}
+	sorted := append([]float64{}, vals...)
+	sort.Float64s(sorted)
+
+	for _, compression := range []float64{10, 100, 1000} {
+		t.Run(fmt.Sprint(compression), func(t *testing.T) {
+			eager := tdigest.New(tdigest.WithCompression(compression))
+			eager.SetEagerAdd(true)
+			eager.AddAll(vals[:len(vals)-1000])
+			if err := eager.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Once the TDigest has filled, values are merged into the
+			// centroids they land near rather than starting new ones.
+			before := eager.CentroidCount()
+			eager.AddAll(vals[len(vals)-1000:])
+			if got := eager.CentroidCount(); got > before+10 {
+				t.Errorf("got CentroidCount() = %v after adding 1000 values, want at most %v", got, before+10)
+			}
+
+			// Eager mode may skip a neighbor the default would choose, so with few
+			// centroids its error can exceed the default's by a fraction of the
+			// observations in an average centroid.
+			lazy := tdigest.New(tdigest.WithCompression(compression))
+			lazy.AddAll(vals)
+			slack := 0.25 / float64(eager.CentroidCount())
+			for _, q := range []float64{0.001, 0.01, 0.1, 0.5, 0.9, 0.99, 0.999} {
+				got := rankError(sorted, q, mustFloat(eager.Quantile(q)))
+				want := rankError(sorted, q, mustFloat(lazy.Quantile(q)))
+				if got > 2*want+slack {
+					t.Errorf("got Quantile(%v) rank error %v in eager mode, want about %v", q, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTDigest_AddAll(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	vals := make([]float64, 10000)
+	for i := range vals {
+		vals[i] = r.NormFloat64()
+	}
+
+	got, want := tdigest.New(tdigest.WithCompression(20)), tdigest.New(tdigest.WithCompression(20))
+	got.AddAll(vals)
+	for _, val := range vals {
+		want.Add(val)
+	}
+
+	if got.String() != want.String() {
+		t.Error("got different centroids from AddAll than from Add")
+	}
+
+	got.AddAll(nil)
+	got.AddAll([]float64{})
+	if got.String() != want.String() {
+		t.Error("got centroids changed by adding no values")
+	}
+}
+
+func benchmarkAddAll(b *testing.B, n int, addAll bool) {
+	r := rand.New(rand.NewSource(1))
+	vals := make([]float64, n)
+	for i := range vals {
+		vals[i] = r.Float64()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		digest := tdigest.New(tdigest.WithCompression(500))
+		if addAll {
+			digest.AddAll(vals)
+			continue
+		}
+		for _, val := range vals {
+			digest.Add(val)
+		}
+	}
+}
+
+func BenchmarkTDigest_AddAll(b *testing.B) {
+	for _, n := range []int{1000, 10000, 1000000} {
+		b.Run(fmt.Sprintf("AddAll/%d", n), func(b *testing.B) {
+			benchmarkAddAll(b, n, true)
+		})
+		b.Run(fmt.Sprintf("Add/%d", n), func(b *testing.B) {
+			benchmarkAddAll(b, n, false)
+		})
+	}
+}
+
+func TestTDigest_AddSorted(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	vals := make([]float64, 20000)
+	for i := range vals {
+		vals[i] = r.NormFloat64()
+	}
+	first, second := vals[:10000], vals[10000:]
+	sort.Float64s(first)
+	sort.Float64s(second)
+
+	digest := tdigest.New(tdigest.WithCompression(20))
+	if err := digest.AddSorted(first); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := mustFloat(digest.Min()), first[0]; got != want {
+		t.Errorf("got Min() = %v for empty digest, want %v", got, want)
+	}
+	if got, want := mustFloat(digest.Max()), first[len(first)-1]; got != want {
+		t.Errorf("got Max() = %v for empty digest, want %v", got, want)
+	}
+	if got, want := mustFloat(digest.Quantile(0.5)), first[len(first)/2]; math.Abs(got-want) > 0.01 {
+		t.Errorf("got Quantile(0.5) = %v for empty digest, want %v +/- 0.01", got, want)
+	}
+
+	// Adding to a digest which already has centroids merges with them.
+	if err := digest.AddSorted(second); err != nil {
+		t.Fatal(err)
+	}
+	sort.Float64s(vals)
+
+	if err := digest.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := digest.LoadFactor(); got > 1 {
+		t.Errorf("got LoadFactor() = %v, want at most 1", got)
+	}
+	if got := digest.Count(); got != float64(len(vals)) {
+		t.Errorf("got Count() = %v, want %v", got, len(vals))
+	}
+	if got := mustFloat(digest.Min()); got != vals[0] {
+		t.Errorf("got Min() = %v, want %v", got, vals[0])
+	}
+	if got := mustFloat(digest.Max()); got != vals[len(vals)-1] {
+		t.Errorf("got Max() = %v, want %v", got, vals[len(vals)-1])
+	}
+
+	// AddSorted uses the limits Add does, so it is about as accurate as adding
+	// the values in random order.
+	shuffled := append([]float64{}, vals...)
+	r.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	added := tdigest.New(tdigest.WithCompression(20))
+	added.AddAll(shuffled)
+	for _, q := range []float64{0.001, 0.01, 0.1, 0.5, 0.9, 0.99, 0.999} {
+		got := rankError(vals, q, mustFloat(digest.Quantile(q)))
+		want := rankError(vals, q, mustFloat(added.Quantile(q)))
+		if got > 2*want+0.0005 {
+			t.Errorf("got Quantile(%v) rank error %v, want about %v as with Add", q, got, want)
+		}
+	}
+}
+
+func TestTDigest_AddSorted_Invalid(t *testing.T) {
+	tcs := []struct {
+		name string
+		vals []float64
+	}{{
+		name: "unsorted",
+		vals: []float64{1, 3, 2},
+	}, {
+		name: "NaN",
+		vals: []float64{1, math.NaN()},
+	}, {
+		name: "infinite",
+		vals: []float64{1, math.Inf(1)},
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			digest := tdigest.New(tdigest.WithCompression(20))
+			if err := digest.AddSorted(tc.vals); err == nil {
+				t.Error("got no error")
+			}
+			if got := digest.Count(); got != 0 {
+				t.Errorf("got Count() = %v, want 0", got)
+			}
+		})
+	}
+}
+
+func BenchmarkTDigest_AddSorted(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	vals := make([]float64, 1000000)
+	for i := range vals {
+		vals[i] = r.Float64()
+	}
+	sort.Float64s(vals)
+
+	b.Run("AddSorted", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = tdigest.New(tdigest.WithCompression(500)).AddSorted(vals)
+		}
+	})
+	b.Run("AddAll", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = tdigest.New(tdigest.WithCompression(500)).AddAll(vals)
+		}
+	})
+}
+
+func TestTDigest_CountMinMax(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(20))
+	if got := digest.Count(); got != 0 {
+		t.Errorf("got Count() = %v for empty digest, want 0", got)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	min, max := math.Inf(1), math.Inf(-1)
+	for i := 0; i < 10000; i++ {
+		val := r.NormFloat64()
+		digest.Add(val)
+		min = math.Min(min, val)
+		max = math.Max(max, val)
+	}
+
+	if got := digest.Count(); got != 10000 {
+		t.Errorf("got Count() = %v, want %v", got, 10000)
+	}
+	// The outermost centroids hold more than one observation, so their means
+	// are not the extremes.
+	if got := mustFloat(digest.Min()); got != min {
+		t.Errorf("got Min() = %v, want %v", got, min)
+	}
+	if got := mustFloat(digest.Max()); got != max {
+		t.Errorf("got Max() = %v, want %v", got, max)
+	}
+
+	other := tdigest.New(tdigest.WithCompression(20))
+	other.Add(-10)
+	other.Add(10)
+	other.Add(0)
+	digest.Merge(other)
+	if got := mustFloat(digest.Min()); got != -10 {
+		t.Errorf("got Min() = %v after merge, want -10", got)
+	}
+	if got := mustFloat(digest.Max()); got != 10 {
+		t.Errorf("got Max() = %v after merge, want 10", got)
+	}
+}
+
+func TestTDigest_Empty(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(20))
+
+	tcs := []struct {
+		name  string
+		query func() (float64, error)
+	}{{
+		name:  "Quantile",
+		query: func() (float64, error) { return digest.Quantile(0.5) },
+	}, {
+		name:  "CDF",
+		query: func() (float64, error) { return digest.CDF(0) },
+	}, {
+		name:  "Min",
+		query: digest.Min,
+	}, {
+		name:  "Max",
+		query: digest.Max,
+	}, {
+		name:  "Mean",
+		query: digest.Mean,
+	}, {
+		name:  "Variance",
+		query: digest.Variance,
+	}, {
+		name:  "StdDev",
+		query: digest.StdDev,
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.query()
+			if err != tdigest.ErrEmptyDigest {
+				t.Errorf("got error %v, want %v", err, tdigest.ErrEmptyDigest)
+			}
+			if !math.IsNaN(got) {
+				t.Errorf("got %v, want NaN", got)
+			}
+		})
+	}
+}
+
+func TestTDigest_MustQuantile(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(20))
+	digest.Add(3)
+	if got := digest.MustQuantile(0.5); got != 3 {
+		t.Errorf("got MustQuantile(0.5) = %v, want 3", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic from MustQuantile on empty digest")
+		}
+	}()
+	tdigest.New(tdigest.WithCompression(20)).MustQuantile(0.5)
+}
+
+func TestTDigest_Quantile_Monotonic(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	distributions := []struct {
+		name string
+		next func() float64
+	}{{
+		name: "uniform",
+		next: r.Float64,
+	}, {
+		name: "normal",
+		next: r.NormFloat64,
+	}, {
+		name: "exponential",
+		next: r.ExpFloat64,
+	}, {
+		name: "discrete",
+		next: func() float64 { return float64(r.Intn(5)) },
+	}}
+	compressions := []float64{1, 5, 20, 100}
+
+	qs := make([]float64, 200)
+	for i := 0; i < 1000; i++ {
+		dist := distributions[i%len(distributions)]
+		compression := compressions[r.Intn(len(compressions))]
+		n := 1 + r.Intn(5000)
+
+		digest := tdigest.New(tdigest.WithCompression(compression))
+		for j := 0; j < n; j++ {
+			digest.Add(dist.next())
+		}
+
+		for j := range qs {
+			qs[j] = r.Float64()
+		}
+		qs[0], qs[1] = 0, 1
+		sort.Float64s(qs)
+
+		prev := mustFloat(digest.Quantile(qs[0]))
+		for _, q := range qs[1:] {
+			got := mustFloat(digest.Quantile(q))
+			if got < prev {
+				t.Fatalf("%s, compression %v, %d values: got Quantile(%v) = %v, less than %v for a lower quantile",
+					dist.name, compression, n, q, got, prev)
+			}
+			prev = got
+		}
+	}
+}
+
+func TestTDigest_Quantile_Monotonic_Fractional(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	weighted := make([]tdigest.CentroidData, 5)
+	for i := range weighted {
+		weighted[i] = tdigest.CentroidData{Mean: float64(i), Count: r.Float64() + 0.1}
+	}
+
+	tcs := []struct {
+		name      string
+		centroids []tdigest.CentroidData
+	}{{
+		name:      "less than two observations",
+		centroids: []tdigest.CentroidData{{Mean: 1, Count: 0.3}, {Mean: 2, Count: 0.3}, {Mean: 3, Count: 0.3}},
+	}, {
+		name:      "fractional counts",
+		centroids: []tdigest.CentroidData{{Mean: 1, Count: 1.5}, {Mean: 2, Count: 2.5}, {Mean: 3, Count: 0.5}},
+	}, {
+		name:      "random weights",
+		centroids: weighted,
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			digest, err := tdigest.FromCentroids(100, tc.centroids)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			prev := mustFloat(digest.Quantile(0))
+			for i := 1; i <= 100; i++ {
+				q := float64(i) / 100
+				got := mustFloat(digest.Quantile(q))
+				if got < prev {
+					t.Fatalf("got Quantile(%v) = %v, less than %v for a lower quantile", q, got, prev)
+				}
+				prev = got
+			}
+		})
+	}
+}
+
+func TestTDigest_Quantile_Extremes(t *testing.T) {
+	for _, n := range []int{1, 2, 10, 100000} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			digest := tdigest.New(tdigest.WithCompression(20))
+			min, max := math.Inf(1), math.Inf(-1)
+			for i := 0; i < n; i++ {
+				val := r.ExpFloat64()
+				digest.Add(val)
+				min = math.Min(min, val)
+				max = math.Max(max, val)
+			}
+
+			if got := mustFloat(digest.Quantile(0)); got != min {
+				t.Errorf("got Quantile(0) = %v, want %v", got, min)
+			}
+			if got := mustFloat(digest.Quantile(1)); got != max {
+				t.Errorf("got Quantile(1) = %v, want %v", got, max)
+			}
+
+			qs := []float64{0, 1e-9, 1e-3, 0.999, 1 - 1e-9, 1}
+			got, err := digest.Quantiles(qs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, q := range qs {
+				if got[i] < min || got[i] > max {
+					t.Errorf("got Quantiles()[%d] = %v for q = %v, outside [%v, %v]", i, got[i], q, min, max)
+				}
+			}
+		})
+	}
+}
+
+func TestTDigest_MeanVariance(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(20))
+
+	r := rand.New(rand.NewSource(1))
+	vals := make([]float64, 100000)
+	var sum float64
+	for i := range vals {
+		vals[i] = 3 + 2*r.NormFloat64()
+		digest.Add(vals[i])
+		sum += vals[i]
+	}
+	mean := sum / float64(len(vals))
+	var sumSquares float64
+	for _, val := range vals {
+		sumSquares += (val - mean) * (val - mean)
+	}
+	variance := sumSquares / float64(len(vals))
+
+	if got := mustFloat(digest.Mean()); math.Abs(got-mean) > 1e-9 {
+		t.Errorf("got Mean() = %v, want %v", got, mean)
+	}
+	// The spread within centroids is lost, so the variance is only
+	// approximate.
+	if got := mustFloat(digest.Variance()); math.Abs(got-variance) > 0.01*variance {
+		t.Errorf("got Variance() = %v, want %v +/- 1%%", got, variance)
+	}
+	if got := mustFloat(digest.StdDev()); math.Abs(got-math.Sqrt(variance)) > 0.01*math.Sqrt(variance) {
+		t.Errorf("got StdDev() = %v, want %v +/- 1%%", got, math.Sqrt(variance))
+	}
+}
+
+func TestTDigest_Mean_Exact(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(20))
+	for i := 0; i < 1000000; i++ {
+		digest.Add(float64(i%1000) + 0.5)
+	}
+	if got := mustFloat(digest.Mean()); math.Abs(got-500) > 1e-9 {
+		t.Errorf("got Mean() = %v, want 500", got)
+	}
+
+	// Merging keeps the sum exactly.
+	other := tdigest.New(tdigest.WithCompression(20))
+	for i := 0; i < 1000; i++ {
+		other.AddWeighted(2500, 1000)
+	}
+	digest.Merge(other)
+	if got := mustFloat(digest.Mean()); math.Abs(got-1500) > 1e-9 {
+		t.Errorf("got Mean() = %v after Merge, want 1500", got)
+	}
+}
+
+func TestTDigest_Clone(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	before := make([]float64, 10000)
+	for i := range before {
+		before[i] = r.Float64()
+	}
+	afterOriginal := make([]float64, 1000)
+	afterClone := make([]float64, 1000)
+	for i := range afterOriginal {
+		afterOriginal[i] = 2 + r.Float64()
+		afterClone[i] = -2 - r.Float64()
+	}
+
+	digest := tdigest.New(tdigest.WithCompression(20))
+	digest.AddAll(before)
+	clone := digest.Clone()
+	digest.AddAll(afterOriginal)
+	clone.AddAll(afterClone)
+
+	if mustFloat(digest.Quantile(0.5)) == mustFloat(clone.Quantile(0.5)) {
+		t.Errorf("got Quantile(0.5) = %v for both digests after diverging", mustFloat(digest.Quantile(0.5)))
+	}
+
+	want := tdigest.New(tdigest.WithCompression(20))
+	want.AddAll(before)
+	want.AddAll(afterClone)
+	if got, want := mustFloat(clone.Quantile(0.5)), mustFloat(want.Quantile(0.5)); got != want {
+		t.Errorf("got clone Quantile(0.5) = %v, want %v", got, want)
+	}
+	if got, want := clone.String(), want.String(); got != want {
+		t.Errorf("got clone centroids %v, want %v", got, want)
+	}
+}
+
+func TestTDigest_Add_Invalid(t *testing.T) {
+	for _, val := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		digest := newUniformRange(20, 1000, 0, 1, 1)
+		want := digest.String()
+
+		if err := digest.Add(val); !errors.Is(err, tdigest.ErrInvalidValue) {
+			t.Errorf("got Add(%v) error %v, want %v", val, err, tdigest.ErrInvalidValue)
+		}
+		if err := digest.AddAll([]float64{0.5, val}); !errors.Is(err, tdigest.ErrInvalidValue) {
+			t.Errorf("got AddAll error %v with %v, want %v", err, val, tdigest.ErrInvalidValue)
+		}
+		if err := digest.AddWeighted(val, 2); !errors.Is(err, tdigest.ErrInvalidValue) {
+			t.Errorf("got AddWeighted(%v) error %v, want %v", val, err, tdigest.ErrInvalidValue)
+		}
+
+		if got := digest.String(); got != want {
+			t.Errorf("got centroids modified by adding %v", val)
+		}
+		if got := digest.Count(); got != 1000 {
+			t.Errorf("got Count() = %v after adding %v, want 1000", got, val)
+		}
+		if got := mustFloat(digest.Max()); got > 1 {
+			t.Errorf("got Max() = %v after adding %v", got, val)
+		}
+	}
+}
+
+func TestTDigest_MustAdd(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(20))
+	digest.MustAdd(1)
+	if got := digest.Count(); got != 1 {
+		t.Errorf("got Count() = %v, want 1", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic adding NaN")
+		}
+	}()
+	digest.MustAdd(math.NaN())
+}
+
+// mustFloat returns v, failing the test by panicking if err is non-nil.
+func mustFloat(v float64, err error) float64 {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// newWithCentroids returns a TDigest built by adding uniform values until it
+// has n centroids. Centroids are created in the order values arrive, not in
+// order of mean, as in real use.
+func newWithCentroids(n int) *tdigest.TDigest {
+	digest := tdigest.New(tdigest.WithCompression(0.01))
+	r := rand.New(rand.NewSource(1))
+	for len(digest.Centroids()) < n {
+		for i := 0; i < 100; i++ {
+			digest.Add(r.Float64())
+		}
+	}
+	return digest
+}
+
+// BenchmarkTDigest_Centroids measures Add and Quantile on digests with 1000
+// and 10000 centroids, where the centroid layout in memory matters most.
+func BenchmarkTDigest_Centroids(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		r := rand.New(rand.NewSource(1))
+
+		b.Run(fmt.Sprintf("Add/%d", n), func(b *testing.B) {
+			digest := newWithCentroids(n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				digest.Add(r.Float64())
+			}
+		})
+		b.Run(fmt.Sprintf("Quantile/%d", n), func(b *testing.B) {
+			digest := newWithCentroids(n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = digest.Quantile(r.Float64())
+			}
+		})
+	}
+}
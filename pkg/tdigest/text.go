@@ -0,0 +1,84 @@
+package tdigest
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"strconv"
+)
+
+var (
+	_ encoding.TextMarshaler   = (*TDigest)(nil)
+	_ encoding.TextUnmarshaler = (*TDigest)(nil)
+)
+
+// MarshalText encodes the TDigest as its compression, count and centroids, as
+// in "100;3;1:1,2.5:2". Values are written with full precision, so
+// UnmarshalText restores the same centroids.
+//
+// The exact minimum, maximum and sum aren't part of the format. When
+// unmarshalling, they are computed from the centroids, so quantiles beyond the
+// middle of the outermost centroids may differ.
+func (d *TDigest) MarshalText() ([]byte, error) {
+	b := strconv.AppendFloat(nil, d.Compression(), 'g', -1, 64)
+	b = append(b, ';')
+	b = strconv.AppendFloat(b, d.count, 'g', -1, 64)
+	b = append(b, ';')
+	for i, c := range d.centroids {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendFloat(b, c.mean, 'g', -1, 64)
+		b = append(b, ':')
+		b = strconv.AppendFloat(b, c.count, 'g', -1, 64)
+	}
+	return b, nil
+}
+
+// UnmarshalText replaces the TDigest with one encoded by MarshalText. The
+// compression must be positive and finite, and the centroids must be sorted by
+// increasing mean.
+func (d *TDigest) UnmarshalText(b []byte) error {
+	fields := bytes.Split(b, []byte{';'})
+	if len(fields) != 3 {
+		return fmt.Errorf("tdigest: got %d fields in text TDigest, want 3", len(fields))
+	}
+
+	compression, err := strconv.ParseFloat(string(fields[0]), 64)
+	if err != nil {
+		return fmt.Errorf("tdigest: invalid compression: %w", err)
+	}
+	if err := validateCompression(compression); err != nil {
+		return err
+	}
+	count, err := strconv.ParseFloat(string(fields[1]), 64)
+	if err != nil {
+		return fmt.Errorf("tdigest: invalid count: %w", err)
+	}
+
+	var centroids []centroid
+	if len(fields[2]) > 0 {
+		pairs := bytes.Split(fields[2], []byte{','})
+		centroids = make([]centroid, len(pairs))
+		for i, pair := range pairs {
+			mean, c, ok := bytes.Cut(pair, []byte{':'})
+			if !ok {
+				return fmt.Errorf("tdigest: centroid %d, %q, has no count", i, pair)
+			}
+			if centroids[i].mean, err = strconv.ParseFloat(string(mean), 64); err != nil {
+				return fmt.Errorf("tdigest: centroid %d: invalid mean: %w", i, err)
+			}
+			if centroids[i].count, err = strconv.ParseFloat(string(c), 64); err != nil {
+				return fmt.Errorf("tdigest: centroid %d: invalid count: %w", i, err)
+			}
+		}
+	}
+	if err := validateCentroids(centroids, count); err != nil {
+		return err
+	}
+
+	d.reset()
+	d.compression = compression
+	d.setCentroids(centroids)
+	return nil
+}
@@ -0,0 +1,142 @@
+package tdigest_test
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTDigest_MarshalText(t *testing.T) {
+	digest := tdigest.New(tdigest.WithCompression(1))
+	for _, val := range []float64{0.1, 2, 2, 3e10} {
+		digest.Add(val)
+	}
+
+	b, err := digest.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), "1;4;0.1:1,2:2,3e+10:1"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	b, err = tdigest.New(tdigest.WithCompression(20)).MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), "20;0;"; got != want {
+		t.Errorf("got %q for empty digest, want %q", got, want)
+	}
+}
+
+// textInput is a random TDigest for checking properties of MarshalText with
+// testing/quick. It may be empty.
+type textInput struct {
+	digest *tdigest.TDigest
+}
+
+func (textInput) Generate(r *rand.Rand, _ int) reflect.Value {
+	distributions := []func() float64{r.Float64, r.NormFloat64, r.ExpFloat64}
+	dist := distributions[r.Intn(len(distributions))]
+	loc, scale := 100*r.NormFloat64(), math.Exp(4*r.NormFloat64())
+
+	digest := tdigest.New(tdigest.WithCompression(math.Exp(4 * r.NormFloat64())))
+	for i := r.Intn(5000); i > 0; i-- {
+		digest.Add(loc + scale*dist())
+	}
+	return reflect.ValueOf(textInput{digest: digest})
+}
+
+func TestTDigest_UnmarshalText(t *testing.T) {
+	f := func(in textInput) bool {
+		b, err := in.digest.MarshalText()
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		got := tdigest.New()
+		if err = got.UnmarshalText(b); err != nil {
+			t.Logf("got error %v unmarshalling %q", err, b)
+			return false
+		}
+
+		// The minimum, maximum and sum aren't part of the format, so compare
+		// only the compression and centroids.
+		if got.Compression() != in.digest.Compression() || !reflect.DeepEqual(got.Centroids(), in.digest.Centroids()) {
+			t.Logf("got centroids %v, want %v", got, in.digest)
+			return false
+		}
+		centroids := in.digest.Centroids()
+		if len(centroids) == 0 {
+			return true
+		}
+		// Quantiles beyond the middle of the outermost centroids are
+		// interpolated towards the exact minimum and maximum, which aren't
+		// part of the format.
+		count := in.digest.Count()
+		lo, hi := centroids[0].Count/2/count, 1-centroids[len(centroids)-1].Count/2/count
+		for i := 0; i <= 100; i++ {
+			q := float64(i) / 100
+			if q < lo || q > hi {
+				continue
+			}
+			if g, w := mustFloat(got.Quantile(q)), mustFloat(in.digest.Quantile(q)); g != w {
+				t.Logf("got Quantile(%v) = %v, want %v", q, g, w)
+				return false
+			}
+		}
+
+		again, _ := got.MarshalText()
+		return bytes.Equal(again, b)
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 50}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTDigest_UnmarshalText_Invalid(t *testing.T) {
+	tcs := []struct {
+		name string
+		b    string
+	}{{
+		name: "empty",
+		b:    "",
+	}, {
+		name: "missing centroids",
+		b:    "100;2",
+	}, {
+		name: "extra field",
+		b:    "100;2;1:2;3",
+	}, {
+		name: "zero compression",
+		b:    "0;2;1:2",
+	}, {
+		name: "invalid count",
+		b:    "100;two;1:2",
+	}, {
+		name: "centroid without count",
+		b:    "100;2;1",
+	}, {
+		name: "invalid mean",
+		b:    "100;2;one:2",
+	}, {
+		name: "unsorted",
+		b:    "100;2;2:1,1:1",
+	}, {
+		name: "miscounted",
+		b:    "100;3;1:1,2:1",
+	}}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tdigest.New().UnmarshalText([]byte(tc.b)); err == nil {
+				t.Error("got no error")
+			}
+		})
+	}
+}
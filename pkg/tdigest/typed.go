@@ -0,0 +1,49 @@
+package tdigest
+
+// Float is the constraint on the type of values in a TypedDigest.
+type Float interface {
+	~float32 | ~float64
+}
+
+// TypedDigest is a TDigest of values of type T, for callers whose data is
+// float32 and would otherwise convert every value. Centroids are still float64,
+// so values are converted as they are added and estimates as they are
+// returned.
+type TypedDigest[T Float] struct {
+	digest *TDigest
+}
+
+// TDigest32 is a TypedDigest of float32 values.
+type TDigest32 = TypedDigest[float32]
+
+// TDigest64 is a TypedDigest of float64 values.
+type TDigest64 = TypedDigest[float64]
+
+// NewTyped returns an empty TypedDigest configured by opts, as New does.
+func NewTyped[T Float](opts ...Option) *TypedDigest[T] {
+	return &TypedDigest[T]{digest: New(opts...)}
+}
+
+// Add adds val to the TypedDigest. If val is NaN or infinite, it returns
+// ErrInvalidValue and leaves the TypedDigest unchanged.
+func (d *TypedDigest[T]) Add(val T) error {
+	return d.digest.Add(float64(val))
+}
+
+// Quantile returns the estimated value of quantile q, or ErrEmptyDigest if the
+// TypedDigest is empty. q is clamped to [0, 1].
+func (d *TypedDigest[T]) Quantile(q float64) (T, error) {
+	val, err := d.digest.Quantile(q)
+	return T(val), err
+}
+
+// CDF returns the estimated fraction of observations less than or equal to x,
+// or ErrEmptyDigest if the TypedDigest is empty.
+func (d *TypedDigest[T]) CDF(x T) (float64, error) {
+	return d.digest.CDF(float64(x))
+}
+
+// Count returns the number of observations added.
+func (d *TypedDigest[T]) Count() float64 {
+	return d.digest.Count()
+}
@@ -0,0 +1,78 @@
+package tdigest_test
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestTypedDigest(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	d32 := tdigest.NewTyped[float32](tdigest.WithCompression(20))
+	d64 := tdigest.NewTyped[float64](tdigest.WithCompression(20))
+	want := tdigest.New(tdigest.WithCompression(20))
+	for i := 0; i < 10000; i++ {
+		val := float32(r.NormFloat64())
+		if err := d32.Add(val); err != nil {
+			t.Fatal(err)
+		}
+		if err := d64.Add(float64(val)); err != nil {
+			t.Fatal(err)
+		}
+		want.Add(float64(val))
+	}
+
+	if d32.Count() != want.Count() || d64.Count() != want.Count() {
+		t.Errorf("got counts %v and %v, want %v", d32.Count(), d64.Count(), want.Count())
+	}
+	for _, q := range []float64{0, 0.01, 0.5, 0.99, 1} {
+		wantQ := mustFloat(want.Quantile(q))
+		if got, err := d32.Quantile(q); err != nil || got != float32(wantQ) {
+			t.Errorf("got TDigest32 Quantile(%v) = %v, %v, want %v", q, got, err, float32(wantQ))
+		}
+		if got, err := d64.Quantile(q); err != nil || got != wantQ {
+			t.Errorf("got TDigest64 Quantile(%v) = %v, %v, want %v", q, got, err, wantQ)
+		}
+	}
+	for _, x := range []float32{-1, 0, 0.5} {
+		if got, want := mustFloat(d32.CDF(x)), mustFloat(want.CDF(float64(x))); got != want {
+			t.Errorf("got CDF(%v) = %v, want %v", x, got, want)
+		}
+	}
+
+	if err := d32.Add(float32(math.Inf(1))); !errors.Is(err, tdigest.ErrInvalidValue) {
+		t.Errorf("got error %v adding +Inf, want %v", err, tdigest.ErrInvalidValue)
+	}
+	var empty *tdigest.TDigest32 = tdigest.NewTyped[float32]()
+	if got, err := empty.Quantile(0.5); !errors.Is(err, tdigest.ErrEmptyDigest) || !math.IsNaN(float64(got)) {
+		t.Errorf("got Quantile(0.5) = %v, %v on empty TDigest32, want NaN, %v", got, err, tdigest.ErrEmptyDigest)
+	}
+}
+
+func BenchmarkTypedDigest_Add(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	vals := make([]float64, 1<<16)
+	for i := range vals {
+		vals[i] = r.NormFloat64()
+	}
+	vals32 := make([]float32, len(vals))
+	for i, val := range vals {
+		vals32[i] = float32(val)
+	}
+
+	b.Run("float32", func(b *testing.B) {
+		d := tdigest.NewTyped[float32]()
+		for i := 0; i < b.N; i++ {
+			_ = d.Add(vals32[i%len(vals32)])
+		}
+	})
+	b.Run("float64", func(b *testing.B) {
+		d := tdigest.NewTyped[float64]()
+		for i := 0; i < b.N; i++ {
+			_ = d.Add(vals[i%len(vals)])
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package tdigest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Validate returns an error describing every violated invariant of the
+// TDigest, or nil if it is consistent. It checks that centroids are sorted by
+// mean, have positive counts, and sum to the total count, and that the cached
+// number of centroids is correct.
+//
+// Validate is O(n) in the number of centroids and is meant for tests and
+// debugging, not hot paths.
+//
+// Centroids may share a mean, since a value equal to a full centroid's mean
+// starts a new centroid beside it.
+func (d *TDigest) Validate() error {
+	var violations []string
+	if d.nCentroids != len(d.centroids) {
+		violations = append(violations, fmt.Sprintf("nCentroids is %d, but there are %d centroids",
+			d.nCentroids, len(d.centroids)))
+	}
+
+	var total float64
+	for i, c := range d.centroids {
+		if !(c.count > 0) {
+			violations = append(violations, fmt.Sprintf("centroid %d has non-positive count %v", i, c.count))
+		}
+		if i > 0 && !(c.mean >= d.centroids[i-1].mean) {
+			violations = append(violations, fmt.Sprintf("centroid %d with mean %v is less than previous mean %v",
+				i, c.mean, d.centroids[i-1].mean))
+		}
+		total += c.count
+	}
+	// Allow for rounding in digests built from weighted centroids.
+	if math.Abs(total-d.count) > 1e-9*math.Max(1, d.count) {
+		violations = append(violations, fmt.Sprintf("count is %v, but centroid counts sum to %v", d.count, total))
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.New("tdigest: invalid TDigest: " + strings.Join(violations, "; "))
+}
@@ -0,0 +1,67 @@
+package tdigest
+
+import "fmt"
+
+// WindowTDigest approximates a sliding window over the most recent
+// observations with two TDigests: the current window, which is being filled,
+// and the previous one. Queries cover both.
+//
+// The current window is rotated to previous after every windowSize
+// observations, or whenever Rotate is called. So once the first window has
+// filled, queries cover between windowSize and 2*windowSize-1 observations.
+// For finer steps, see RollingDigest.
+type WindowTDigest struct {
+	windowSize int
+
+	current      *TDigest
+	currentCount int
+
+	previous *TDigest
+}
+
+// NewWindow panics if windowSize is not positive.
+func NewWindow(windowSize int, compression float64) *WindowTDigest {
+	if windowSize <= 0 {
+		panic(fmt.Sprintf("tdigest: window size must be positive, got %d", windowSize))
+	}
+	return &WindowTDigest{
+		windowSize: windowSize,
+		current:    New(WithCompression(compression)),
+		previous:   New(WithCompression(compression)),
+	}
+}
+
+// Add adds val to the current window, rotating it if it is full. It returns
+// ErrInvalidValue if val is NaN or infinite.
+func (d *WindowTDigest) Add(val float64) error {
+	if err := d.current.Add(val); err != nil {
+		return err
+	}
+	d.currentCount++
+	if d.currentCount >= d.windowSize {
+		d.Rotate()
+	}
+	return nil
+}
+
+// Rotate makes the current window the previous one, discarding the previous
+// window, and starts an empty current window.
+//
+// Add rotates automatically every windowSize observations. Callers who want
+// time-based windows can also call Rotate on a timer, such as every 30 seconds
+// to always cover at least the last 30 seconds.
+func (d *WindowTDigest) Rotate() {
+	// Reuse the discarded window's centroid slice for the new one.
+	d.previous.Reset()
+	d.previous, d.current = d.current, d.previous
+	d.currentCount = 0
+}
+
+// Quantile returns the quantile q of the observations in the current and
+// previous windows, or ErrEmptyDigest if both are empty.
+func (d *WindowTDigest) Quantile(q float64) (float64, error) {
+	merged := New(WithCompression(d.current.compression))
+	merged.Accumulate(d.previous)
+	merged.Accumulate(d.current)
+	return merged.Quantile(q)
+}
@@ -0,0 +1,66 @@
+package tdigest_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/willbeason/tdigest/pkg/tdigest"
+)
+
+func TestWindowTDigest(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	digest := tdigest.NewWindow(1000, 20)
+
+	if _, err := digest.Quantile(0.5); err != tdigest.ErrEmptyDigest {
+		t.Errorf("got error %v for empty window, want %v", err, tdigest.ErrEmptyDigest)
+	}
+
+	for i := 0; i < 1000; i++ {
+		digest.Add(r.Float64())
+	}
+	if got := mustFloat(digest.Quantile(0.5)); math.Abs(got-0.5) > 0.05 {
+		t.Errorf("got Quantile(0.5) = %v, want approximately 0.5", got)
+	}
+
+	// Half of a window from the new distribution is merged with the old
+	// window.
+	for i := 0; i < 500; i++ {
+		digest.Add(10 + r.Float64())
+	}
+	if got := mustFloat(digest.Quantile(0.5)); got > 1.1 {
+		t.Errorf("got Quantile(0.5) = %v with two-thirds old values, want at most 1.1", got)
+	}
+	if got := mustFloat(digest.Quantile(0.9)); got < 10 {
+		t.Errorf("got Quantile(0.9) = %v with a third new values, want at least 10", got)
+	}
+
+	// After another window, the old values are gone.
+	for i := 0; i < 1000; i++ {
+		digest.Add(10 + r.Float64())
+	}
+	if got := mustFloat(digest.Quantile(0)); got < 10 {
+		t.Errorf("got Quantile(0) = %v after old window rotated out, want at least 10", got)
+	}
+}
+
+func TestWindowTDigest_Rotate(t *testing.T) {
+	digest := tdigest.NewWindow(1000, 20)
+	digest.Add(1)
+	digest.Rotate()
+	digest.Add(2)
+
+	if got := mustFloat(digest.Quantile(0)); got != 1 {
+		t.Errorf("got Quantile(0) = %v, want 1 from the previous window", got)
+	}
+
+	digest.Rotate()
+	if got := mustFloat(digest.Quantile(0)); got != 2 {
+		t.Errorf("got Quantile(0) = %v, want 2 after rotating out 1", got)
+	}
+
+	digest.Rotate()
+	if _, err := digest.Quantile(0.5); err != tdigest.ErrEmptyDigest {
+		t.Errorf("got error %v after rotating out every value, want %v", err, tdigest.ErrEmptyDigest)
+	}
+}